@@ -2,7 +2,10 @@ package appconf
 
 import (
 	"context"
+	"errors"
+	"io/fs"
 	"log"
+	"muu-alpha/backend/migrate"
 	"os"
 	"path/filepath"
 	"sync"
@@ -37,13 +40,27 @@ func Startup(ctx context.Context) {
 	s := AppConf()
 	s.ctx = ctx
 
-	if err := s.loadConfig(); err != nil {
+	changed, err := s.loadConfig()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) && !migrate.IsDecodeError(err) {
+		// the file may be fine, keep it and run with the defaults
+		log.Printf("load app config failed, using the default config: %v", err)
+		s.config = DefaultAppConfig()
+		return
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) && s.recoverConfig() {
+		err = nil
+	}
+	if err != nil {
 		log.Printf("load app config failed, initializing default config: %v", err)
 		s.config = DefaultAppConfig()
 
 		if err := s.saveConfig(); err != nil {
 			log.Printf("save default app config failed: %v", err)
 		}
+	} else if changed {
+		if err := s.saveConfig(); err != nil {
+			log.Printf("save fixed app config failed: %v", err)
+		}
 	}
 
 	log.Printf("app config loaded: theme=%s, language=%s", s.config.Theme, s.config.Language)
@@ -1,8 +1,68 @@
 package appconf
 
+import (
+	"encoding/json"
+	"muu-alpha/backend/migrate"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
 type AppConfig struct {
-	Theme    Theme    `json:"theme"`
-	Language Language `json:"language"`
+	ConfigVersion int          `json:"config_version,omitempty"`
+	Theme         Theme        `json:"theme"`
+	Language      Language     `json:"language"`
+	Remote        RemoteConfig `json:"remote"`
+
+	// Extra holds the fields this version does not know, they are written back unchanged
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// configMigrations upgrades app_config.json, the index of a migration is the version it upgrades from
+var configMigrations = migrate.Chain{
+	{From: 0, Description: "add config_version", Apply: func(doc migrate.Doc) error { return nil }},
+}
+
+// ConfigVersion is the version of app_config.json written by this build,
+// it must match the migrations, which is checked by the tests
+const ConfigVersion = 1
+
+func (c *AppConfig) UnmarshalJSON(data []byte) error {
+	type plain AppConfig
+	if err := json.Unmarshal(data, (*plain)(c)); err != nil {
+		return err
+	}
+	extra, err := migrate.UnknownFields(data, plain{})
+	if err != nil {
+		return err
+	}
+	c.Extra = extra
+	return nil
+}
+
+func (c AppConfig) MarshalJSON() ([]byte, error) {
+	type plain AppConfig
+	data, err := json.Marshal(plain(c))
+	if err != nil {
+		return nil, err
+	}
+	return migrate.MergeUnknown(data, c.Extra)
+}
+
+// RemoteConfig configures the HTTP/WebSocket remote control API
+type RemoteConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Address  string `json:"address"`             // bind address, e.g. "127.0.0.1:7788"
+	Token    string `json:"token"`               // bearer token required by every request
+	CertFile string `json:"cert_file,omitempty"` // TLS is enabled when both files are set
+	KeyFile  string `json:"key_file,omitempty"`
+}
+
+const DefaultRemoteAddress = "127.0.0.1:7788"
+
+// TLS reports whether the remote API is served over TLS
+func (c RemoteConfig) TLS() bool {
+	return c.CertFile != "" && c.KeyFile != ""
 }
 
 type Theme string
@@ -76,7 +136,17 @@ func isValidLanguage(l Language) bool {
 
 func DefaultAppConfig() *AppConfig {
 	return &AppConfig{
-		Theme:    ThemeSystem,
-		Language: LangZhCN,
+		ConfigVersion: ConfigVersion,
+		Theme:         ThemeSystem,
+		Language:      LangZhCN,
+		Remote: RemoteConfig{
+			Address: DefaultRemoteAddress,
+			Token:   newRemoteToken(),
+		},
 	}
 }
+
+// newRemoteToken generates a random token for the remote API
+func newRemoteToken() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")
+}
@@ -0,0 +1,12 @@
+package appconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigMigrations(t *testing.T) {
+	require.NoError(t, configMigrations.Validate())
+	require.Equal(t, configMigrations.Latest(), ConfigVersion)
+}
@@ -4,7 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"muu-alpha/backend/events"
+	"muu-alpha/backend/migrate"
+	"muu-alpha/backend/safefile"
 	"os"
+	"path/filepath"
 	"sync"
 )
 
@@ -16,34 +21,65 @@ type service struct {
 }
 
 // loadConfig loads config from file
-func (s *service) loadConfig() error {
+// Returns true if invalid values were fixed and the file should be saved
+func (s *service) loadConfig() (bool, error) {
 	s.configMu.Lock()
 	defer s.configMu.Unlock()
 
 	data, err := os.ReadFile(s.configPath)
 	if err != nil {
-		return fmt.Errorf("read config file failed: %w", err)
+		return false, fmt.Errorf("read config file failed: %w", err)
+	}
+
+	migrated, from, err := configMigrations.Migrate(data)
+	if err != nil {
+		return false, fmt.Errorf("parse config file failed: %w", err)
 	}
 
 	var config AppConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return fmt.Errorf("parse config file failed: %w", err)
+	if err := json.Unmarshal(migrated, &config); err != nil {
+		return false, fmt.Errorf("parse config file failed: %w", err)
 	}
 
-	s.checkConfig(&config)
+	changed := s.checkConfig(&config)
+
+	switch {
+	case from > ConfigVersion:
+		log.Printf("app config has version %d, newer than %d, unknown fields are kept", from, ConfigVersion)
+	case from < ConfigVersion:
+		backup, err := migrate.Backup(s.configPath, from)
+		if err != nil {
+			return false, fmt.Errorf("backup app config before migration failed: %w", err)
+		}
+		log.Printf("app config migrated from version %d to %d, backup at %s", from, ConfigVersion, backup)
+		changed = true
+	}
 
 	s.config = &config
-	return nil
+	return changed, nil
 }
 
 // checkConfig checks and fixes invalid values
-func (s *service) checkConfig(config *AppConfig) {
+// Returns true if any value was fixed
+func (s *service) checkConfig(config *AppConfig) bool {
+	changed := false
 	if !isValidTheme(config.Theme) {
 		config.Theme = ThemeSystem
+		changed = true
 	}
 	if !isValidLanguage(config.Language) {
 		config.Language = LangZhCN
+		changed = true
 	}
+	if config.Remote.Address == "" {
+		config.Remote.Address = DefaultRemoteAddress
+		changed = true
+	}
+	if config.Remote.Token == "" {
+		config.Remote.Token = newRemoteToken()
+		changed = true
+	}
+	return changed
 }
 
 // saveConfig saves config to file
@@ -61,13 +97,69 @@ func (s *service) saveConfig() error {
 		return fmt.Errorf("marshal config failed: %w", err)
 	}
 
-	if err := os.WriteFile(s.configPath, data, 0644); err != nil {
+	if err := safefile.Backup(s.configPath, safefile.MaxBackups); err != nil {
+		log.Printf("backup app config failed: %v", err)
+	}
+	if err := safefile.WriteFile(s.configPath, data, 0644); err != nil {
 		return fmt.Errorf("write config file failed: %w", err)
 	}
 
 	return nil
 }
 
+// validConfig checks that data can be loaded as an app config
+func validConfig(data []byte) error {
+	migrated, _, err := configMigrations.Migrate(data)
+	if err != nil {
+		return err
+	}
+	var config AppConfig
+	return json.Unmarshal(migrated, &config)
+}
+
+// recoverConfig restores the config file from the newest valid backup after it failed to load.
+// The unreadable file is moved aside. Returns false when the config has to be reset to defaults.
+func (s *service) recoverConfig() bool {
+	if _, err := os.Stat(s.configPath); err != nil {
+		// first start, nothing was lost
+		return false
+	}
+
+	recovered := events.ConfigRecovered{Path: s.configPath}
+	corrupt, err := safefile.Quarantine(s.configPath)
+	if err != nil {
+		log.Printf("move unreadable app config aside failed: %v", err)
+	}
+	recovered.Corrupt = corrupt
+
+	defer func() {
+		events.Publish(recovered)
+		if recovered.Backup != "" {
+			events.Publish(events.Warn(fmt.Sprintf("app config could not be read, restored from backup %s", filepath.Base(recovered.Backup))))
+		} else {
+			events.Publish(events.Warn("app config could not be read, reset to defaults"))
+		}
+	}()
+
+	data, backup, err := safefile.Recover(s.configPath, validConfig)
+	if err != nil {
+		log.Printf("recover app config failed: %v", err)
+		return false
+	}
+	if err := safefile.WriteFile(s.configPath, data, 0644); err != nil {
+		log.Printf("restore app config from %s failed: %v", backup, err)
+		return false
+	}
+	if _, err := s.loadConfig(); err != nil {
+		log.Printf("load restored app config failed: %v", err)
+		return false
+	}
+
+	log.Printf("app config restored from %s", backup)
+	recovered.Backup = backup
+	return true
+}
+
 // ==================== frontend exposed interfaces ====================
 
 // GetConfig gets full config
@@ -81,8 +173,11 @@ func (s *service) GetConfig() *AppConfig {
 
 	// return a copy to avoid external modification
 	return &AppConfig{
-		Theme:    s.config.Theme,
-		Language: s.config.Language,
+		ConfigVersion: s.config.ConfigVersion,
+		Theme:         s.config.Theme,
+		Language:      s.config.Language,
+		Remote:        s.config.Remote,
+		Extra:         s.config.Extra,
 	}
 }
 
@@ -99,15 +194,46 @@ func (s *service) SaveConfig(config *AppConfig) error {
 	if !isValidLanguage(config.Language) {
 		return fmt.Errorf("invalid language: %s", config.Language)
 	}
+	if (config.Remote.CertFile == "") != (config.Remote.KeyFile == "") {
+		return fmt.Errorf("remote api tls requires both cert_file and key_file")
+	}
+
+	remote := config.Remote
+	if remote.Address == "" {
+		remote.Address = DefaultRemoteAddress
+	}
 
 	s.configMu.Lock()
+	// keep the current token when the caller does not set one
+	if remote.Token == "" && s.config != nil {
+		remote.Token = s.config.Remote.Token
+	}
+	if remote.Token == "" {
+		remote.Token = newRemoteToken()
+	}
+	// the frontend drops the fields it does not know
+	extra := config.Extra
+	if extra == nil && s.config != nil {
+		extra = s.config.Extra
+	}
 	s.config = &AppConfig{
-		Theme:    config.Theme,
-		Language: config.Language,
+		ConfigVersion: ConfigVersion,
+		Theme:         config.Theme,
+		Language:      config.Language,
+		Remote:        remote,
+		Extra:         extra,
 	}
 	s.configMu.Unlock()
 
-	return s.saveConfig()
+	if err := s.saveConfig(); err != nil {
+		return err
+	}
+
+	events.Publish(events.AppConfigChanged{
+		Theme:    string(config.Theme),
+		Language: string(config.Language),
+	})
+	return nil
 }
 
 // GetSupported gets supported
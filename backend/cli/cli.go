@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+// command is a headless subcommand of the application
+type command struct {
+	Name  string
+	Usage string
+	Run   func(args []string) int
+}
+
+var commands = []command{
+	{
+		Name:  "run",
+		Usage: "run the checked tasks of a config without the GUI",
+		Run:   runCommand,
+	},
+	{
+		Name:  "lint",
+		Usage: "validate an interface.json and report every issue",
+		Run:   lintCommand,
+	},
+}
+
+// IsCommand reports whether args (without the program name) select a headless subcommand
+func IsCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	return isHelp(args[0]) || findCommand(args[0]) != nil
+}
+
+// Main runs the subcommand selected by args (without the program name) and returns the exit code
+func Main(args []string) int {
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return ExitUsage
+	}
+
+	if isHelp(args[0]) {
+		printUsage(os.Stdout)
+		return ExitOK
+	}
+
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", args[0])
+		printUsage(os.Stderr)
+		return ExitUsage
+	}
+
+	return cmd.Run(args[1:])
+}
+
+func isHelp(arg string) bool {
+	switch arg {
+	case "help", "-h", "-help", "--help":
+		return true
+	default:
+		return false
+	}
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].Name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", programName())
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.Name, cmd.Usage)
+	}
+	fmt.Fprintf(w, "\nStart without a command to open the GUI.\n")
+}
+
+func programName() string {
+	return filepath.Base(os.Args[0])
+}
+
+// newFlagSet creates a flag set for a subcommand that reports errors instead of exiting
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(programName()+" "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"muu-alpha/backend/pi"
+	"os"
+	"path/filepath"
+)
+
+// lintResult is the machine-readable output of the lint command
+type lintResult struct {
+	File     string     `json:"file"`
+	Errors   int        `json:"errors"`
+	Warnings int        `json:"warnings"`
+	Issues   []pi.Issue `json:"issues"`
+
+	Coverage *pi.CoverageReport `json:"coverage,omitempty"`
+}
+
+// lintCommand validates an interface file and reports every issue
+func lintCommand(args []string) int {
+	fs := newFlagSet("lint")
+	format := fs.String("format", "text", "output format: text or json")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s lint [flags] <interface.json>\n\nFlags:\n", programName())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if fs.NArg() != 1 || (*format != "text" && *format != "json") {
+		fs.Usage()
+		return ExitUsage
+	}
+	path := fs.Arg(0)
+
+	report, err := pi.LintFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return ExitError
+	}
+
+	// translation coverage of an interface that can be decoded at all
+	coverage, err := pi.CoverageFile(path)
+	if err == nil {
+		report.Issues = append(report.Issues, coverage.Issues()...)
+	}
+
+	result := lintResult{
+		File:     path,
+		Errors:   len(report.Errors()),
+		Warnings: len(report.Warnings()),
+		Issues:   report.Issues,
+		Coverage: coverage,
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitError
+		}
+	} else {
+		for _, issue := range result.Issues {
+			// issues of imported files are printed with the path of that file
+			file := path
+			if issue.File != "" {
+				file = filepath.Join(filepath.Dir(path), issue.File)
+				issue.File = ""
+			}
+			fmt.Printf("%s: %s\n", file, issue)
+		}
+		fmt.Printf("%d errors, %d warnings\n", result.Errors, result.Warnings)
+	}
+
+	if result.Errors > 0 {
+		return ExitError
+	}
+	return ExitOK
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"muu-alpha/backend/engine"
+	"muu-alpha/backend/events"
+	"muu-alpha/backend/history"
+	"muu-alpha/backend/pi"
+	"os"
+	"time"
+)
+
+// runCommand loads an interface and a config, then runs the checked tasks to completion
+func runCommand(args []string) int {
+	fs := newFlagSet("run")
+	ifacePath := fs.String("interface", pi.ActiveProjectPath(), "path to interface.json, defaults to the last opened project")
+	configPath := fs.String("config", "", "path to interface_config.json, defaults to the config of the project")
+	profile := fs.String("profile", "", "name of a config profile of the project, instead of --config")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if *configPath != "" && *profile != "" {
+		fmt.Fprintln(os.Stderr, "--config and --profile cannot be used together")
+		return ExitUsage
+	}
+	if *configPath == "" {
+		path, err := pi.ProfileConfigPath(*ifacePath, *profile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+		*configPath = path
+	}
+
+	// The run command never creates a config, a missing file is most likely a typo
+	if _, err := os.Stat(*configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "config not found: %v\n", err)
+		return ExitError
+	}
+
+	if err := pi.Load(*ifacePath, *configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "load interface failed: %v\n", err)
+		return ExitError
+	}
+
+	if err := engine.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "init maa framework failed: %v\n", err)
+		return ExitError
+	}
+
+	tasks := engine.GetTaskList()
+	if len(tasks) == 0 {
+		fmt.Fprintln(os.Stderr, "no checked tasks in config")
+		return ExitError
+	}
+
+	// Record headless runs in the same history as the GUI
+	history.Startup(context.Background())
+
+	unsubscribe := events.Subscribe(printProgress)
+	defer unsubscribe()
+
+	fmt.Printf("running %d tasks\n", len(tasks))
+	start := time.Now()
+	if err := engine.Engine().Run(tasks); err != nil {
+		fmt.Fprintf(os.Stderr, "run failed after %s: %v\n", time.Since(start).Round(time.Second), err)
+		return ExitError
+	}
+
+	fmt.Printf("all tasks succeeded in %s\n", time.Since(start).Round(time.Second))
+	return ExitOK
+}
+
+// printProgress prints engine events to stdout
+func printProgress(e events.Event) {
+	switch e := e.(type) {
+	case events.EngineTask:
+		prefix := fmt.Sprintf("[%d/%d] %s", e.Index+1, e.Total, e.Entry)
+		if e.Done() && e.FinishedAt != nil {
+			fmt.Printf("%s: %s (%s)\n", prefix, e.Status, e.FinishedAt.Sub(e.StartedAt).Round(time.Millisecond))
+		} else {
+			fmt.Printf("%s: %s\n", prefix, e.Status)
+		}
+	case events.Notice:
+		if e.Level == events.NoticeError {
+			fmt.Fprintf(os.Stderr, "error: %s\n", e.Message)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunProfileTraversal(t *testing.T) {
+	ifacePath := filepath.Join(t.TempDir(), "interface.json")
+	require.Equal(t, ExitUsage, runCommand([]string{"--interface", ifacePath, "--profile", "../interface_config"}))
+}
@@ -2,6 +2,9 @@ package engine
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 	"muu-alpha/backend/pi"
 	"os"
 	"path/filepath"
@@ -23,20 +26,35 @@ func Engine() *service {
 }
 
 func Startup(ctx context.Context) {
+	if err := Init(); err != nil {
+		log.Printf("init maa framework failed: %v", err)
+	}
+	s := Engine()
+	s.ctx = ctx
+
+	// Switching projects mid-run would pull the interface from under the tasker
+	pi.SetProjectGuard(func() error {
+		if s.GetIsRunning() {
+			return errors.New("cannot switch projects while tasks are running")
+		}
+		return nil
+	})
+}
+
+// Init loads the MaaFramework libraries shipped next to the executable
+func Init() error {
 	exePath, err := os.Executable()
 	if err != nil {
-		panic(err) // todo
+		return fmt.Errorf("get executable path failed: %w", err)
 	}
 	exeDir := filepath.Dir(exePath)
 	libDir := filepath.Join(exeDir, "lib")
 	logDir := filepath.Join(exeDir, "logs")
 
-	maa.Init(
+	return maa.Init(
 		maa.WithLibDir(libDir),
 		maa.WithLogDir(logDir),
 	)
-	s := Engine()
-	s.ctx = ctx
 }
 
 func GetPathsByResName(name string) []string {
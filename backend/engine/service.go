@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"muu-alpha/backend/events"
 	"muu-alpha/backend/pi"
 	"os"
 	"os/exec"
@@ -17,12 +18,11 @@ import (
 	"github.com/MaaXYZ/maa-framework-go/v3"
 	"github.com/MaaXYZ/maa-framework-go/v3/controller/adb"
 	"github.com/MaaXYZ/maa-framework-go/v3/controller/win32"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
-const (
-	EventEngineRunning = "engine:running"
-	EventAppError      = "app:error"
+var (
+	ErrAlreadyRunning = errors.New("engine is already running")
+	ErrStopped        = errors.New("engine stopped")
 )
 
 type service struct {
@@ -47,31 +47,77 @@ func (s *service) GetIsRunning() bool {
 }
 
 func (s *service) Start() {
+	go func() {
+		if err := s.Run(nil); err != nil {
+			log.Println("engine run failed:", err)
+		}
+	}()
+}
+
+// StartProfile starts running the checked tasks of a named config profile without making it active
+func (s *service) StartProfile(name string) error {
+	config, err := pi.PI().LoadProfile(name)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := s.run(config, nil); err != nil {
+			log.Println("engine run failed:", err)
+		}
+	}()
+	return nil
+}
+
+// Run initializes the engine and runs the task list to completion.
+// A nil taskList runs the tasks selected in the current config.
+// It returns an error if initialization fails or any task does not succeed.
+func (s *service) Run(taskList []*Task) error {
+	return s.run(pi.PI().GetConfig(), taskList)
+}
+
+// RunProfile runs the checked tasks of a named config profile to completion without making it active
+func (s *service) RunProfile(name string) error {
+	config, err := pi.PI().LoadProfile(name)
+	if err != nil {
+		return err
+	}
+	return s.run(config, nil)
+}
+
+// run runs the task list with the controller and resource of piConf,
+// a nil taskList runs the tasks checked in piConf
+func (s *service) run(piConf *pi.InterfaceConfig, taskList []*Task) error {
 	// Use write lock to prevent race condition (TOCTOU)
 	s.mu.Lock()
 	if s.isRunning {
 		s.mu.Unlock()
 		log.Println("engine is already running")
-		return
+		return ErrAlreadyRunning
 	}
 	s.isRunning = true
 	s.mu.Unlock()
 
 	log.Println("engine starting...")
-	runtime.EventsEmit(s.ctx, EventEngineRunning, true)
+	events.Publish(events.EngineRunning{Running: true})
 
 	// helper to handle initialization errors safely
-	handleInitError := func(err error, cleanupFunc func()) {
+	handleInitError := func(err error, cleanupFunc func()) error {
 		log.Println("engine start failed:", err)
-		runtime.EventsEmit(s.ctx, EventAppError, err.Error())
+		events.Publish(events.Error(err.Error()))
 
 		if cleanupFunc != nil {
 			cleanupFunc()
 		}
 		s.mu.Lock()
+		// a Stop during the initialization already published the stop
+		wasRunning := s.isRunning
 		s.isRunning = false
 		s.mu.Unlock()
-		runtime.EventsEmit(s.ctx, EventEngineRunning, false)
+		if wasRunning {
+			events.Publish(events.EngineRunning{Running: false})
+		}
+		events.Publish(events.EngineFinished{Error: err.Error()})
+		return err
 	}
 
 	tasker := maa.NewTasker()
@@ -102,47 +148,39 @@ func (s *service) Start() {
 		}
 	}
 
-	piSrv := pi.PI()
-	v2Loaded := piSrv.V2Loaded()
-	piConf := piSrv.GetConfig()
+	v2Loaded := pi.PI().V2Loaded()
 	if v2Loaded == nil || v2Loaded.Interface == nil || piConf == nil {
-		handleInitError(errors.New("v2 loaded or interface or config is nil"), localCleanup)
-		return
+		return handleInitError(errors.New("v2 loaded or interface or config is nil"), localCleanup)
 	}
 	iface := v2Loaded.Interface
 
 	var err error
 
 	// init res
-	res, err = s.createRes(iface, piConf)
+	res, err = s.createRes(iface, v2Loaded.BasePath, piConf)
 	if err != nil {
-		handleInitError(fmt.Errorf("failed to create resource: %w", err), localCleanup)
-		return
+		return handleInitError(fmt.Errorf("failed to create resource: %w", err), localCleanup)
 	}
 
 	// init ctrl
 	ctrl, err = s.createCtrl(iface, piConf)
 	if err != nil {
-		handleInitError(fmt.Errorf("failed to create controller: %w", err), localCleanup)
-		return
+		return handleInitError(fmt.Errorf("failed to create controller: %w", err), localCleanup)
 	}
 
 	// init agent
 	if iface.Agent != nil {
-		agent, agentCmd, err = s.createAgent(iface, res)
+		agent, agentCmd, err = s.createAgent(iface, v2Loaded.BasePath, res)
 		if err != nil {
-			handleInitError(fmt.Errorf("failed to create agent: %w", err), localCleanup)
-			return
+			return handleInitError(fmt.Errorf("failed to create agent: %w", err), localCleanup)
 		}
 	}
 
 	if !tasker.BindResource(res) {
-		handleInitError(errors.New("failed to bind resource to tasker"), localCleanup)
-		return
+		return handleInitError(errors.New("failed to bind resource to tasker"), localCleanup)
 	}
 	if !tasker.BindController(ctrl) {
-		handleInitError(errors.New("failed to bind controller to tasker"), localCleanup)
-		return
+		return handleInitError(errors.New("failed to bind controller to tasker"), localCleanup)
 	}
 
 	s.mu.Lock()
@@ -151,7 +189,8 @@ func (s *service) Start() {
 		s.mu.Unlock()
 		log.Println("engine start aborted (stopped during init)")
 		localCleanup() // Clean up the local objects we just created
-		return
+		events.Publish(events.EngineFinished{Error: ErrStopped.Error()})
+		return ErrStopped
 	}
 	s.tasker = tasker
 	s.res = res
@@ -160,34 +199,79 @@ func (s *service) Start() {
 	s.agentCmd = agentCmd
 	s.mu.Unlock()
 
-	taskList := GetTaskList()
+	if taskList == nil {
+		taskList = TaskListFor(piConf)
+	}
 
-	go func() {
-		defer s.Stop()
-		for _, task := range taskList {
-			// Hold read lock while checking and getting tasker reference
-			s.mu.RLock()
-			running := s.isRunning
-			tasker := s.tasker
-			s.mu.RUnlock()
-
-			if !running || tasker == nil {
-				return
-			}
+	succeeded, failed := 0, 0
+	for i, task := range taskList {
+		// Hold read lock while checking and getting tasker reference
+		s.mu.RLock()
+		running := s.isRunning
+		tasker := s.tasker
+		s.mu.RUnlock()
 
-			task.StartedAt = time.Now()
-			pipelineOverride := "{}"
-			if string(task.PipelineOverride) != "" {
-				pipelineOverride = string(task.PipelineOverride)
+		if !running || tasker == nil {
+			// a Stop of the user already published the stop
+			if running {
+				s.Stop()
 			}
-			job := tasker.PostTask(task.Entry, pipelineOverride).Wait()
-			task.Status = job.Status()
-			task.FinishedAt = time.Now()
+			events.Publish(events.EngineFinished{Succeeded: succeeded, Failed: failed, Error: ErrStopped.Error()})
+			return ErrStopped
 		}
-	}()
+
+		task.StartedAt = time.Now()
+		task.Status = maa.StatusRunning
+		events.Publish(newTaskEvent(task, i, len(taskList)))
+
+		pipelineOverride := "{}"
+		if string(task.PipelineOverride) != "" {
+			pipelineOverride = string(task.PipelineOverride)
+		}
+		job := tasker.PostTask(task.Entry, pipelineOverride).Wait()
+		task.Status = job.Status()
+		task.FinishedAt = time.Now()
+		events.Publish(newTaskEvent(task, i, len(taskList)))
+
+		if task.Status.Success() {
+			succeeded++
+		} else {
+			failed++
+		}
+	}
+
+	s.Stop()
+
+	finished := events.EngineFinished{Succeeded: succeeded, Failed: failed}
+	if failed > 0 {
+		err := fmt.Errorf("%d of %d tasks failed", failed, len(taskList))
+		finished.Error = err.Error()
+		events.Publish(finished)
+		return err
+	}
+	events.Publish(finished)
+	return nil
 }
 
-func (s *service) createRes(iface *pi.V2Interface, piConf *pi.InterfaceConfig) (*maa.Resource, error) {
+// newTaskEvent creates the progress event of the index-th task in a queue of total tasks
+func newTaskEvent(task *Task, index int, total int) events.EngineTask {
+	e := events.EngineTask{
+		ID:        task.ID,
+		Entry:     task.Entry,
+		Index:     index,
+		Total:     total,
+		Status:    task.Status.String(),
+		StartedAt: task.StartedAt,
+	}
+	if !task.FinishedAt.IsZero() {
+		finishedAt := task.FinishedAt
+		e.FinishedAt = &finishedAt
+	}
+	return e
+}
+
+// createRes creates the resource and posts the bundles, bundle paths are relative to the project basePath
+func (s *service) createRes(iface *pi.V2Interface, basePath string, piConf *pi.InterfaceConfig) (*maa.Resource, error) {
 	bundles := make([]string, 0)
 	for _, res := range iface.Resource {
 		if res.Name == piConf.Resource {
@@ -205,14 +289,8 @@ func (s *service) createRes(iface *pi.V2Interface, piConf *pi.InterfaceConfig) (
 		return nil, errors.New("failed to create resource instance")
 	}
 
-	exeDir, err := s.getExecutableDir()
-	if err != nil {
-		res.Destroy()
-		return nil, err
-	}
-
 	for _, bundle := range bundles {
-		bundlePath := filepath.Join(exeDir, bundle)
+		bundlePath := filepath.Join(basePath, bundle)
 		if !res.PostBundle(bundlePath).Wait().Success() {
 			res.Destroy()
 			return nil, fmt.Errorf("failed to post bundle: %s", bundlePath)
@@ -332,7 +410,8 @@ func (s *service) createWin32Ctrl(iface *pi.V2Interface) (*maa.Controller, error
 	return ctrl, nil
 }
 
-func (s *service) createAgent(iface *pi.V2Interface, res *maa.Resource) (*maa.AgentClient, *exec.Cmd, error) {
+// createAgent starts the agent child process in the project basePath and connects to it
+func (s *service) createAgent(iface *pi.V2Interface, basePath string, res *maa.Resource) (*maa.AgentClient, *exec.Cmd, error) {
 	identifier := iface.Agent.Identifier
 
 	agent := maa.NewAgentClient(identifier)
@@ -353,12 +432,7 @@ func (s *service) createAgent(iface *pi.V2Interface, res *maa.Resource) (*maa.Ag
 	id, _ := agent.Identifier()
 	cmd := exec.Command(iface.Agent.ChildExec, append(iface.Agent.ChildArgs, id)...)
 
-	exeDir, err := s.getExecutableDir()
-	if err != nil {
-		cleanup()
-		return nil, nil, err
-	}
-	cmd.Dir = exeDir
+	cmd.Dir = basePath
 
 	if err := cmd.Start(); err != nil {
 		cleanup()
@@ -430,7 +504,7 @@ func (s *service) Stop() {
 		_ = agentCmd.Wait()
 	}
 
-	runtime.EventsEmit(s.ctx, EventEngineRunning, false)
+	events.Publish(events.EngineRunning{Running: false})
 	log.Println("engine stopped")
 }
 
@@ -2,9 +2,11 @@ package engine
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
+	"muu-alpha/backend/jsonc"
 	"muu-alpha/backend/pi"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -22,9 +24,12 @@ type Task struct {
 
 // GetTaskList gets the list of selected tasks, merging all PipelineOverride
 func GetTaskList() []*Task {
-	piService := pi.PI()
-	config := piService.GetConfig()
-	v2Loaded := piService.V2Loaded()
+	return TaskListFor(pi.PI().GetConfig())
+}
+
+// TaskListFor builds the task list of the tasks checked in config
+func TaskListFor(config *pi.InterfaceConfig) []*Task {
+	v2Loaded := pi.PI().V2Loaded()
 
 	tasks := make([]*Task, 0)
 
@@ -51,8 +56,9 @@ func GetTaskList() []*Task {
 			continue
 		}
 
-		// Merge PipelineOverride
-		mergedOverride := mergePipelineOverrides(v2Task, configTask.Option, iface.Option)
+		// Merge PipelineOverride of the options shown with the current values
+		options := pi.ShownOptions(iface, config, configTask)
+		mergedOverride := mergePipelineOverrides(v2Task, options, iface.Option, pi.ScopeOf(config, options))
 
 		// Serialize merged PipelineOverride
 		var overrideJSON json.RawMessage
@@ -73,25 +79,25 @@ func GetTaskList() []*Task {
 }
 
 // mergePipelineOverrides merges PipelineOverride for the task and its options
-func mergePipelineOverrides(v2Task *pi.V2Task, configOptions []pi.ConfigTaskOption, optionDefs map[string]pi.V2Option) map[string]map[string]interface{} {
+func mergePipelineOverrides(v2Task *pi.V2Task, configOptions []pi.ConfigTaskOption, optionDefs map[string]pi.V2Option, scope pi.OptionScope) map[string]map[string]interface{} {
 	merged := make(map[string]map[string]interface{})
 
 	// 1. First merge PipelineOverride for the task itself
 	if len(v2Task.PipelineOverride) > 0 {
 		var taskOverride map[string]map[string]interface{}
-		if err := json.Unmarshal(v2Task.PipelineOverride, &taskOverride); err == nil {
+		if err := jsonc.Unmarshal(v2Task.PipelineOverride, &taskOverride); err == nil {
 			mergeOverride(merged, taskOverride)
 		}
 	}
 
 	// 2. Build configuration option value mapping
-	optionValues := make(map[string]string)
+	optionValues := make(map[string]pi.ConfigTaskOption)
 	for _, opt := range configOptions {
-		optionValues[opt.Name] = opt.Value
+		optionValues[opt.Name] = opt
 	}
 
 	// 3. Recursively merge PipelineOverride for options
-	collectOptionOverrides(merged, v2Task.Option, optionValues, optionDefs)
+	collectOptionOverrides(merged, v2Task.Option, optionValues, optionDefs, scope)
 
 	if len(merged) == 0 {
 		return nil
@@ -100,11 +106,11 @@ func mergePipelineOverrides(v2Task *pi.V2Task, configOptions []pi.ConfigTaskOpti
 	return merged
 }
 
-// collectOptionOverrides recursively collects PipelineOverride for options
-func collectOptionOverrides(merged map[string]map[string]interface{}, optionNames []string, optionValues map[string]string, optionDefs map[string]pi.V2Option) {
+// collectOptionOverrides recursively collects PipelineOverride for options, options hidden in scope are skipped
+func collectOptionOverrides(merged map[string]map[string]interface{}, optionNames []string, optionValues map[string]pi.ConfigTaskOption, optionDefs map[string]pi.V2Option, scope pi.OptionScope) {
 	for _, optName := range optionNames {
 		optDef, exists := optionDefs[optName]
-		if !exists {
+		if !exists || !optDef.When.Match(scope, optionDefs) {
 			continue
 		}
 
@@ -113,7 +119,7 @@ func collectOptionOverrides(merged map[string]map[string]interface{}, optionName
 		switch optType {
 		case "select", "switch":
 			// Get current selected value
-			selectedValue := optionValues[optName]
+			selectedValue := optionValues[optName].CaseName()
 			if selectedValue == "" {
 				continue
 			}
@@ -124,39 +130,56 @@ func collectOptionOverrides(merged map[string]map[string]interface{}, optionName
 					// Merge PipelineOverride for this case
 					if len(optCase.PipelineOverride) > 0 {
 						var caseOverride map[string]map[string]interface{}
-						if err := json.Unmarshal(optCase.PipelineOverride, &caseOverride); err == nil {
+						if err := jsonc.Unmarshal(optCase.PipelineOverride, &caseOverride); err == nil {
 							mergeOverride(merged, caseOverride)
 						}
 					}
 
 					// Recursively process nested options
 					if len(optCase.Option) > 0 {
-						collectOptionOverrides(merged, optCase.Option, optionValues, optionDefs)
+						collectOptionOverrides(merged, optCase.Option, optionValues, optionDefs, scope)
 					}
 					break
 				}
 			}
 
+		case "checkbox":
+			// Merge every selected case in declaration order, later cases win
+			for _, optCase := range optDef.SelectedCases(optionValues[optName].CaseNames()) {
+				if len(optCase.PipelineOverride) > 0 {
+					var caseOverride map[string]map[string]interface{}
+					if err := jsonc.Unmarshal(optCase.PipelineOverride, &caseOverride); err == nil {
+						mergeOverride(merged, caseOverride)
+					}
+				}
+
+				// Recursively process nested options
+				if len(optCase.Option) > 0 {
+					collectOptionOverrides(merged, optCase.Option, optionValues, optionDefs, scope)
+				}
+			}
+
 		case "input":
 			// For input type, process option-level PipelineOverride (with variable replacement)
 			if len(optDef.PipelineOverride) > 0 {
 				// Collect input values
+				values := optionValues[optName].InputValues()
 				inputValues := make(map[string]inputValue)
 				for _, input := range optDef.Inputs {
-					key := optName + "." + input.Name
-					value := optionValues[key]
-					if value == "" {
+					// hidden inputs use their default
+					value, ok := values[input.Name]
+					if !ok || !input.When.Match(scope, optionDefs) {
 						value = input.Default
 					}
 					inputValues[input.Name] = inputValue{
-						value:        value,
-						pipelineType: input.PipelineType,
+						value: value,
+						input: input,
 					}
 				}
 
 				// Replace variables and merge
 				var inputOverride map[string]map[string]interface{}
-				if err := json.Unmarshal(optDef.PipelineOverride, &inputOverride); err == nil {
+				if err := jsonc.Unmarshal(optDef.PipelineOverride, &inputOverride); err == nil {
 					// Replace variables
 					processedOverride := replaceVariables(inputOverride, inputValues)
 					mergeOverride(merged, processedOverride)
@@ -166,10 +189,10 @@ func collectOptionOverrides(merged map[string]map[string]interface{}, optionName
 	}
 }
 
-// inputValue stores value and pipelineType for input
+// inputValue stores value and definition for input
 type inputValue struct {
-	value        string
-	pipelineType string
+	value interface{}
+	input pi.V2OptionInput
 }
 
 // replaceVariables replaces variable placeholders in PipelineOverride
@@ -195,8 +218,8 @@ func replaceValue(value interface{}, inputValues map[string]inputValue) interfac
 		if matches := re.FindStringSubmatch(v); len(matches) == 2 {
 			varName := matches[1]
 			if input, exists := inputValues[varName]; exists {
-				// Convert type based on pipeline_type
-				return convertValue(input.value, input.pipelineType)
+				// Convert type based on the kind of the input
+				return convertValue(input.value, input.input)
 			}
 		}
 
@@ -204,7 +227,7 @@ func replaceValue(value interface{}, inputValues map[string]inputValue) interfac
 		result := v
 		for name, input := range inputValues {
 			placeholder := "{" + name + "}"
-			result = strings.ReplaceAll(result, placeholder, input.value)
+			result = strings.ReplaceAll(result, placeholder, textValue(convertValue(input.value, input.input)))
 		}
 		return result
 
@@ -227,19 +250,26 @@ func replaceValue(value interface{}, inputValues map[string]inputValue) interfac
 	}
 }
 
-// convertValue converts value based on pipelineType
-func convertValue(value string, pipelineType string) interface{} {
-	switch pipelineType {
-	case "int":
-		if i, err := strconv.Atoi(value); err == nil {
-			return i
-		}
-		return value
-	case "bool":
-		return value == "true" || value == "True" || value == "1"
-	default:
-		return value
+// convertValue converts value based on the kind of input, values that cannot be converted use the default
+func convertValue(value interface{}, input pi.V2OptionInput) interface{} {
+	converted, err := pi.PipelineValue(value, input)
+	if err == nil {
+		return converted
+	}
+	log.Printf("invalid value %v of input %s, using the default: %v", value, input.Name, err)
+	converted, err = pi.PipelineValue(pi.DefaultInputValue(input), input)
+	if err != nil {
+		return pi.DefaultInputValue(input)
+	}
+	return converted
+}
+
+// textValue returns value as placed inside a string, list items are separated by commas
+func textValue(value interface{}) string {
+	if items, ok := value.([]string); ok {
+		return strings.Join(items, ",")
 	}
+	return fmt.Sprint(value)
 }
 
 // mergeOverride merges two PipelineOverrides, with the new one overriding top-level keys of the old one
@@ -0,0 +1,86 @@
+package events
+
+import (
+	"sync"
+)
+
+// Event is a typed message published on the bus
+type Event interface {
+	// Topic returns the name the event is published under, e.g. "engine:running"
+	Topic() string
+}
+
+// Handler consumes events. Handlers run on the publisher's goroutine and must not block.
+type Handler func(Event)
+
+// Bus is an in-process publish/subscribe event bus
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[int]Handler
+	order    []int
+	nextID   int
+}
+
+// New creates an empty bus
+func New() *Bus {
+	return &Bus{
+		handlers: make(map[int]Handler),
+	}
+}
+
+// Subscribe registers a handler for every event and returns a function that removes it
+func (b *Bus) Subscribe(handler Handler) (unsubscribe func()) {
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+	b.order = append(b.order, id)
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.handlers, id)
+			for i, v := range b.order {
+				if v == id {
+					b.order = append(b.order[:i], b.order[i+1:]...)
+					break
+				}
+			}
+		})
+	}
+}
+
+// Publish delivers the event to every subscriber in subscription order
+func (b *Bus) Publish(event Event) {
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.order))
+	for _, id := range b.order {
+		handlers = append(handlers, b.handlers[id])
+	}
+	b.mu.RUnlock()
+
+	// Call handlers outside the lock so they may subscribe or unsubscribe
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+var defaultBus = New()
+
+// Default returns the application-wide bus
+func Default() *Bus {
+	return defaultBus
+}
+
+// Subscribe registers a handler on the application-wide bus
+func Subscribe(handler Handler) (unsubscribe func()) {
+	return defaultBus.Subscribe(handler)
+}
+
+// Publish publishes an event on the application-wide bus
+func Publish(event Event) {
+	defaultBus.Publish(event)
+}
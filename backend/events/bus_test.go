@@ -0,0 +1,64 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus(t *testing.T) {
+	t.Run("delivers in subscription order", func(t *testing.T) {
+		bus := New()
+		got := []string{}
+		bus.Subscribe(func(e Event) { got = append(got, "a:"+e.Topic()) })
+		bus.Subscribe(func(e Event) { got = append(got, "b:"+e.Topic()) })
+
+		bus.Publish(EngineRunning{Running: true})
+		require.Equal(t, []string{"a:engine:running", "b:engine:running"}, got)
+	})
+
+	t.Run("unsubscribe stops delivery", func(t *testing.T) {
+		bus := New()
+		count := 0
+		unsubscribe := bus.Subscribe(func(e Event) { count++ })
+
+		bus.Publish(EngineRunning{})
+		unsubscribe()
+		unsubscribe()
+		bus.Publish(EngineRunning{})
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("handler may unsubscribe itself", func(t *testing.T) {
+		bus := New()
+		count := 0
+		var unsubscribe func()
+		unsubscribe = bus.Subscribe(func(e Event) {
+			count++
+			unsubscribe()
+		})
+
+		bus.Publish(EngineRunning{})
+		bus.Publish(EngineRunning{})
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("typed payload", func(t *testing.T) {
+		bus := New()
+		var got EngineTask
+		bus.Subscribe(func(e Event) {
+			if task, ok := e.(EngineTask); ok {
+				got = task
+			}
+		})
+
+		bus.Publish(EngineTask{ID: "1", Entry: "StartUp", Status: "success"})
+		require.Equal(t, "StartUp", got.Entry)
+		require.True(t, got.Done())
+	})
+}
+
+func TestNoticeTopic(t *testing.T) {
+	require.Equal(t, "app:error", Error("x").Topic())
+	require.Equal(t, "app:warn", Warn("x").Topic())
+}
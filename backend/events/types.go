@@ -0,0 +1,208 @@
+package events
+
+import "time"
+
+// NoticeLevel is the severity of a user-facing notice
+type NoticeLevel string
+
+const (
+	NoticeError   NoticeLevel = "error"
+	NoticeWarn    NoticeLevel = "warn"
+	NoticeSuccess NoticeLevel = "success"
+	NoticeInfo    NoticeLevel = "info"
+)
+
+// Notice is a message shown to the user, published as "app:<level>"
+type Notice struct {
+	Level   NoticeLevel `json:"level"`
+	Message string      `json:"message"`
+}
+
+func (e Notice) Topic() string { return "app:" + string(e.Level) }
+
+// Error creates an error notice
+func Error(message string) Notice {
+	return Notice{Level: NoticeError, Message: message}
+}
+
+// Warn creates a warning notice
+func Warn(message string) Notice {
+	return Notice{Level: NoticeWarn, Message: message}
+}
+
+// AppReady is published once the backend services have started
+type AppReady struct {
+	Version string `json:"version"`
+}
+
+func (e AppReady) Topic() string { return "system:ready" }
+
+// EngineRunning is published when the engine starts or stops
+type EngineRunning struct {
+	Running bool `json:"running"`
+}
+
+func (e EngineRunning) Topic() string { return "engine:running" }
+
+// EngineTask is published when a task of the running queue starts or finishes
+type EngineTask struct {
+	ID         string     `json:"id"`
+	Entry      string     `json:"entry"`
+	Index      int        `json:"index"`
+	Total      int        `json:"total"`
+	Status     string     `json:"status"` // "running" | "success" | "failure"
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"` // nil while the task is running
+}
+
+func (e EngineTask) Topic() string { return "engine:task" }
+
+// Done reports whether the task has finished
+func (e EngineTask) Done() bool {
+	return e.Status == "success" || e.Status == "failure"
+}
+
+// EngineFinished is published after a run ends, Error is empty on success
+type EngineFinished struct {
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (e EngineFinished) Topic() string { return "engine:finished" }
+
+// InterfaceLoaded is published after the project interface has been loaded
+type InterfaceLoaded struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	BasePath string `json:"base_path"`
+}
+
+func (e InterfaceLoaded) Topic() string { return "pi:loaded" }
+
+// ConfigSaved is published after the interface config has been written
+type ConfigSaved struct {
+	Path string `json:"path"`
+}
+
+func (e ConfigSaved) Topic() string { return "pi:config-saved" }
+
+// AppConfigChanged is published after the app config has been saved
+type AppConfigChanged struct {
+	Theme    string `json:"theme"`
+	Language string `json:"language"`
+}
+
+func (e AppConfigChanged) Topic() string { return "appconf:changed" }
+
+// NameDiff lists the named entries that were added, removed or changed
+type NameDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+// Empty reports whether nothing differs
+func (d NameDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// TaskRef identifies a task of the config
+type TaskRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// TaskRename is a config task renamed through the aliases of an interface task
+type TaskRename struct {
+	ID   string `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SelectionReset is a controller or resource selection that no longer existed
+type SelectionReset struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// InputReset is a stored input value its input does not accept, it was replaced with the default
+type InputReset struct {
+	Task   TaskRef     `json:"task"`
+	Option string      `json:"option"`
+	Input  string      `json:"input"`
+	Value  interface{} `json:"value"`  // the rejected value
+	Reason string      `json:"reason"` // why it was rejected
+}
+
+// CaseReset is a stored case its option no longer has, a checkbox drops it
+// and a select or switch falls back to the default case
+type CaseReset struct {
+	Task   TaskRef `json:"task"`
+	Option string  `json:"option"`
+	Case   string  `json:"case"`
+}
+
+// ConfigSync reports how the config was reconciled with the interface
+type ConfigSync struct {
+	Renamed    []TaskRename    `json:"renamed"`
+	Orphaned   []TaskRef       `json:"orphaned"` // tasks the interface no longer has, kept but never run
+	Added      []TaskRef       `json:"added"`    // default_check tasks added to the interface since the last sync
+	Reset      []InputReset    `json:"reset"`    // invalid input values replaced with their default
+	Cases      []CaseReset     `json:"cases"`    // unknown cases dropped or replaced with the default
+	Controller *SelectionReset `json:"controller,omitempty"`
+	Resource   *SelectionReset `json:"resource,omitempty"`
+}
+
+// Empty reports whether nothing needs the attention of the user
+func (c ConfigSync) Empty() bool {
+	return len(c.Renamed) == 0 && len(c.Orphaned) == 0 && len(c.Added) == 0 && len(c.Reset) == 0 &&
+		len(c.Cases) == 0 && c.Controller == nil && c.Resource == nil
+}
+
+// InterfaceReloaded is published after the interface was reloaded from disk
+type InterfaceReloaded struct {
+	Controllers   NameDiff   `json:"controllers"`
+	Resources     NameDiff   `json:"resources"`
+	Tasks         NameDiff   `json:"tasks"`
+	Options       NameDiff   `json:"options"`
+	Languages     NameDiff   `json:"languages"`
+	ConfigChanged bool       `json:"config_changed"` // the config was synced to the new definitions
+	Sync          ConfigSync `json:"sync"`
+}
+
+func (e InterfaceReloaded) Topic() string { return "pi:reloaded" }
+
+// ProjectChanged is published after another project was opened
+type ProjectChanged struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+}
+
+func (e ProjectChanged) Topic() string { return "pi:project-changed" }
+
+// ProfileChanged is published after another config profile was made active
+type ProfileChanged struct {
+	Name string `json:"name"`
+}
+
+func (e ProfileChanged) Topic() string { return "pi:profile-changed" }
+
+// ConfigRecovered is published when a config file could not be parsed.
+// Backup is the backup it was restored from, empty when it was reset to defaults.
+type ConfigRecovered struct {
+	Path    string `json:"path"`
+	Backup  string `json:"backup"`
+	Corrupt string `json:"corrupt"` // where the unreadable file was moved to
+}
+
+func (e ConfigRecovered) Topic() string { return "config:recovered" }
+
+// ConfigChanged is published after the active config was changed through a binding or the remote API
+type ConfigChanged struct {
+	Revision int64  `json:"revision"`
+	Profile  string `json:"profile"`
+	Change   string `json:"change"` // e.g. "add-task", "replace"
+}
+
+func (e ConfigChanged) Topic() string { return "pi:config-changed" }
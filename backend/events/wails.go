@@ -0,0 +1,15 @@
+package events
+
+import (
+	"context"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ForwardToWails subscribes the webview to the application-wide bus.
+// ctx must be the context given to the Wails OnStartup hook.
+func ForwardToWails(ctx context.Context) (unsubscribe func()) {
+	return Subscribe(func(e Event) {
+		runtime.EventsEmit(ctx, e.Topic(), e)
+	})
+}
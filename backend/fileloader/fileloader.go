@@ -8,10 +8,16 @@ import (
 )
 
 type FileLoader struct {
-	basePath string
+	basePath func() string
 }
 
 func New(basePath string) *FileLoader {
+	return &FileLoader{basePath: func() string { return basePath }}
+}
+
+// NewFunc creates a loader whose base path is looked up on every request,
+// for directories that move at runtime such as the active project
+func NewFunc(basePath func() string) *FileLoader {
 	return &FileLoader{basePath: basePath}
 }
 
@@ -22,7 +28,7 @@ func (fl *FileLoader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	safePath := filepath.Clean(relativePath)
 
-	fullPath := filepath.Join(fl.basePath, safePath)
+	fullPath := filepath.Join(fl.basePath(), safePath)
 
 	info, err := os.Stat(fullPath)
 	if err != nil {
@@ -0,0 +1,41 @@
+package history
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	srvInst *service
+	srvOnce sync.Once
+)
+
+func History() *service {
+	srvOnce.Do(func() {
+		exePath, err := os.Executable()
+		if err != nil {
+			exePath = "."
+		}
+		exeDir := filepath.Dir(exePath)
+
+		srvInst = &service{
+			runs:     []Run{},
+			filePath: filepath.Join(exeDir, "config", "run_history.json"),
+		}
+	})
+	return srvInst
+}
+
+func Startup(ctx context.Context) {
+	s := History()
+	s.ctx = ctx
+
+	if err := s.load(); err != nil && !os.IsNotExist(err) {
+		log.Printf("load run history failed: %v", err)
+	}
+
+	s.subscribe()
+}
@@ -0,0 +1,171 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"muu-alpha/backend/events"
+	"muu-alpha/backend/safefile"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxRuns is the number of runs kept in the history
+const MaxRuns = 100
+
+// Run is one engine run from start to finish
+type Run struct {
+	ID         string              `json:"id"`
+	StartedAt  time.Time           `json:"started_at"`
+	FinishedAt *time.Time          `json:"finished_at,omitempty"` // nil while the run is in progress
+	Succeeded  int                 `json:"succeeded"`
+	Failed     int                 `json:"failed"`
+	Error      string              `json:"error,omitempty"`
+	Tasks      []events.EngineTask `json:"tasks"`
+}
+
+type service struct {
+	ctx         context.Context
+	mu          sync.RWMutex
+	runs        []Run
+	current     *Run
+	filePath    string
+	unsubscribe func()
+}
+
+// subscribe starts recording engine events
+func (s *service) subscribe() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.unsubscribe != nil {
+		return
+	}
+	s.unsubscribe = events.Subscribe(s.handle)
+}
+
+// handle records engine events into the current run
+func (s *service) handle(e events.Event) {
+	switch e := e.(type) {
+	case events.EngineRunning:
+		if !e.Running {
+			return
+		}
+		s.mu.Lock()
+		s.current = &Run{
+			ID:        uuid.New().String(),
+			StartedAt: time.Now(),
+			Tasks:     []events.EngineTask{},
+		}
+		s.mu.Unlock()
+
+	case events.EngineTask:
+		if !e.Done() {
+			return
+		}
+		s.mu.Lock()
+		if s.current != nil {
+			s.current.Tasks = append(s.current.Tasks, e)
+		}
+		s.mu.Unlock()
+
+	case events.EngineFinished:
+		s.mu.Lock()
+		if s.current == nil {
+			s.mu.Unlock()
+			return
+		}
+		run := *s.current
+		s.current = nil
+		finishedAt := time.Now()
+		run.FinishedAt = &finishedAt
+		run.Succeeded = e.Succeeded
+		run.Failed = e.Failed
+		run.Error = e.Error
+
+		s.runs = append(s.runs, run)
+		if len(s.runs) > MaxRuns {
+			s.runs = s.runs[len(s.runs)-MaxRuns:]
+		}
+		s.mu.Unlock()
+
+		if err := s.save(); err != nil {
+			log.Printf("save run history failed: %v", err)
+		}
+	}
+}
+
+// load loads the history from file
+func (s *service) load() error {
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		return err
+	}
+
+	var runs []Run
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return fmt.Errorf("parse history file failed: %w", err)
+	}
+
+	s.mu.Lock()
+	s.runs = runs
+	s.mu.Unlock()
+	return nil
+}
+
+// save saves the history to file
+func (s *service) save() error {
+	s.mu.RLock()
+	data, err := json.MarshalIndent(s.runs, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("marshal history failed: %w", err)
+	}
+
+	if err := safefile.WriteFile(s.filePath, data, 0644); err != nil {
+		return fmt.Errorf("write history file failed: %w", err)
+	}
+	return nil
+}
+
+// ==================== frontend exposed interfaces ====================
+
+// GetRuns gets finished runs, newest first
+func (s *service) GetRuns() []Run {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	runs := make([]Run, len(s.runs))
+	for i, run := range s.runs {
+		runs[len(s.runs)-1-i] = run
+	}
+	return runs
+}
+
+// GetRun gets a finished run by id
+func (s *service) GetRun(id string) (*Run, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := range s.runs {
+		if s.runs[i].ID == id {
+			run := s.runs[i]
+			return &run, nil
+		}
+	}
+	return nil, errors.New("run not found: " + id)
+}
+
+// ClearRuns removes every finished run
+func (s *service) ClearRuns() error {
+	s.mu.Lock()
+	s.runs = []Run{}
+	s.mu.Unlock()
+
+	return s.save()
+}
@@ -0,0 +1,147 @@
+package jsonc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Error is a JSON error at a position of the original text
+type Error struct {
+	Line   int // 1-based
+	Column int // 1-based, in characters
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Standardize returns plain JSON for JSONC data, which may have // and /* */ comments
+// and trailing commas like MaaFramework accepts. They are replaced by spaces and line breaks
+// are kept, so offsets and positions in the result are the same as in data.
+func Standardize(data []byte) []byte {
+	out := make([]byte, len(data))
+	copy(out, data)
+
+	// comments first, so trailing commas can be found by skipping whitespace only
+	inString := false
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				blank(out, i)
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			out[i], out[i+1] = ' ', ' '
+			for i += 2; i < len(out); i++ {
+				if out[i] == '*' && i+1 < len(out) && out[i+1] == '/' {
+					out[i], out[i+1] = ' ', ' '
+					i++
+					break
+				}
+				blank(out, i)
+			}
+		}
+	}
+
+	inString = false
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case ',':
+			j := i + 1
+			for j < len(out) && isSpace(out[j]) {
+				j++
+			}
+			if j < len(out) && (out[j] == '}' || out[j] == ']') {
+				out[i] = ' '
+			}
+		}
+	}
+	return out
+}
+
+// blank replaces the byte at i with a space, keeping line breaks
+func blank(data []byte, i int) {
+	if data[i] != '\n' && data[i] != '\r' {
+		data[i] = ' '
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// Unmarshal parses JSONC data into v. Syntax and type errors are returned as *Error
+// with the position in data, which is the end of the value for type errors.
+func Unmarshal(data []byte, v interface{}) error {
+	err := json.Unmarshal(Standardize(data), v)
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var offset int64
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return err
+	}
+	line, column := Position(data, offset)
+	return &Error{Line: line, Column: column, Err: err}
+}
+
+// Position returns the line and column of the byte before offset, where encoding/json stops
+// at an error. Both are 1-based and the column counts characters.
+func Position(data []byte, offset int64) (line int, column int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	if offset > 0 {
+		offset--
+	}
+	line, column = 1, 1
+	for i := 0; i < int(offset); {
+		if data[i] == '\n' {
+			line++
+			column = 1
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRune(data[i:])
+		column++
+		i += size
+	}
+	return line, column
+}
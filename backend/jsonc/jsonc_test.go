@@ -0,0 +1,65 @@
+package jsonc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandardize(t *testing.T) {
+	type Case struct {
+		name     string
+		input    string
+		expected string
+	}
+
+	testCases := []Case{
+		{name: "plain", input: `{"a": [1, 2]}`, expected: `{"a": [1, 2]}`},
+		{name: "line comment", input: "{\"a\": 1 // one\n}", expected: "{\"a\": 1       \n}"},
+		{name: "block comment keeps lines", input: "{/* a\nb */\"a\": 1}", expected: "{    \n    \"a\": 1}"},
+		{name: "trailing commas", input: `{"a": [1, 2,], }`, expected: `{"a": [1, 2 ]  }`},
+		{name: "comma before comment", input: "[1, // last\n]", expected: "[1         \n]"},
+		{name: "comment markers in strings", input: `{"a": "http://x/*y*/", "b": "\"//,]"}`, expected: `{"a": "http://x/*y*/", "b": "\"//,]"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, string(Standardize([]byte(tc.input))))
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	var v struct {
+		A int   `json:"a"`
+		B []int `json:"b"`
+	}
+	require.NoError(t, Unmarshal([]byte("{\n  // comment\n  \"a\": 1,\n  \"b\": [1, 2,],\n}"), &v))
+	require.Equal(t, 1, v.A)
+	require.Equal(t, []int{1, 2}, v.B)
+
+	type Case struct {
+		name   string
+		input  string
+		line   int
+		column int
+	}
+
+	testCases := []Case{
+		{name: "syntax error after a block comment", input: "{\n/* a\nb */ \"a\": 1 x\n}", line: 3, column: 13},
+		{name: "type error at the end of the value", input: "{\n  \"a\": \"x\"\n}", line: 2, column: 10},
+		{name: "columns count characters", input: `{"b": ["é", 1]}`, line: 1, column: 10},
+		{name: "unexpected end", input: `{"a": 1`, line: 1, column: 7},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Unmarshal([]byte(tc.input), &v)
+			var jsonErr *Error
+			require.True(t, errors.As(err, &jsonErr))
+			require.Equal(t, tc.line, jsonErr.Line)
+			require.Equal(t, tc.column, jsonErr.Column)
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// VersionField is the field holding the version of a config file
+const VersionField = "config_version"
+
+// Doc is a config file decoded down to its top-level fields
+type Doc map[string]json.RawMessage
+
+// Migration upgrades a config from version From to From+1
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(doc Doc) error
+}
+
+// Chain is the ordered list of migrations of a config file.
+// Files without VersionField are version 0.
+type Chain []Migration
+
+// Latest returns the version a config has after every migration
+func (c Chain) Latest() int {
+	return len(c)
+}
+
+// Version returns the version of a config file
+func Version(doc Doc) (int, error) {
+	raw, ok := doc[VersionField]
+	if !ok {
+		return 0, nil
+	}
+	var version int
+	if err := json.Unmarshal(raw, &version); err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", VersionField, err)
+	}
+	return version, nil
+}
+
+// Migrate upgrades data to the latest version.
+// It returns the upgraded data and the version data had. Data already at the latest
+// version, or written by a newer version, is returned unchanged.
+func (c Chain) Migrate(data []byte) ([]byte, int, error) {
+	var doc Doc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+
+	from, err := Version(doc)
+	if err != nil {
+		return nil, 0, err
+	}
+	if from >= c.Latest() {
+		return data, from, nil
+	}
+
+	for _, m := range c[from:] {
+		if err := m.Apply(doc); err != nil {
+			return nil, from, fmt.Errorf("migrate from version %d (%s) failed: %w", m.From, m.Description, err)
+		}
+	}
+	doc[VersionField] = json.RawMessage(fmt.Sprint(c.Latest()))
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, from, err
+	}
+	return out, from, nil
+}
+
+// IsDecodeError reports whether err comes from data that cannot be decoded as a config,
+// as opposed to a file that cannot be read
+func IsDecodeError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+// Validate checks that the migrations are ordered without gaps
+func (c Chain) Validate() error {
+	for i, m := range c {
+		if m.From != i {
+			return fmt.Errorf("migration %d starts from version %d", i, m.From)
+		}
+	}
+	return nil
+}
+
+// Backup copies the file at path before it is migrated from version.
+// An existing backup of the same version is kept, it is closer to the original.
+func Backup(path string, version int) (string, error) {
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	if _, err := os.Stat(backup); err == nil {
+		return backup, nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", err
+	}
+	return backup, dst.Close()
+}
+
+// UnknownFields returns the top-level fields of data that the struct v does not declare
+func UnknownFields(data []byte, v interface{}) (map[string]json.RawMessage, error) {
+	var doc Doc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	known := knownFields(reflect.TypeOf(v))
+	var unknown map[string]json.RawMessage
+	for name, raw := range doc {
+		if known[name] {
+			continue
+		}
+		if unknown == nil {
+			unknown = make(map[string]json.RawMessage)
+		}
+		unknown[name] = raw
+	}
+	return unknown, nil
+}
+
+// MergeUnknown appends unknown fields to the encoded object data, declared fields win
+func MergeUnknown(data []byte, unknown map[string]json.RawMessage) ([]byte, error) {
+	if len(unknown) == 0 {
+		return data, nil
+	}
+
+	var doc Doc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(unknown))
+	for name := range unknown {
+		if _, ok := doc[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// keep the field order of data, json.Marshal of a map would sort it
+	trimmed := bytes.TrimSpace(data)
+	out := append([]byte{}, trimmed[:len(trimmed)-1]...)
+	for _, name := range names {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.HasSuffix(bytes.TrimSpace(out), []byte("{")) {
+			out = append(out, ',')
+		}
+		out = append(out, key...)
+		out = append(out, ':')
+		out = append(out, unknown[name]...)
+	}
+	return append(out, '}'), nil
+}
+
+// knownFields returns the JSON names of the fields of a struct type
+func knownFields(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	known := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		known[name] = true
+	}
+	return known
+}
@@ -0,0 +1,131 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	chain := Chain{
+		{From: 0, Description: "rename name", Apply: func(doc Doc) error {
+			doc["title"] = doc["name"]
+			delete(doc, "name")
+			return nil
+		}},
+		{From: 1, Description: "add count", Apply: func(doc Doc) error {
+			doc["count"] = json.RawMessage("0")
+			return nil
+		}},
+	}
+	require.NoError(t, chain.Validate())
+
+	type Case struct {
+		Name string
+		Data string
+		From int
+		Want map[string]interface{}
+	}
+
+	cases := []Case{
+		{
+			Name: "unversioned",
+			Data: `{"name": "a"}`,
+			From: 0,
+			Want: map[string]interface{}{"title": "a", "count": 0.0, "config_version": 2.0},
+		},
+		{
+			Name: "partially migrated",
+			Data: `{"config_version": 1, "title": "a"}`,
+			From: 1,
+			Want: map[string]interface{}{"title": "a", "count": 0.0, "config_version": 2.0},
+		},
+		{
+			Name: "newer version is untouched",
+			Data: `{"config_version": 5, "other": true}`,
+			From: 5,
+			Want: map[string]interface{}{"other": true, "config_version": 5.0},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			out, from, err := chain.Migrate([]byte(c.Data))
+			require.NoError(t, err)
+			require.Equal(t, c.From, from)
+
+			var got map[string]interface{}
+			require.NoError(t, json.Unmarshal(out, &got))
+			require.Equal(t, c.Want, got)
+		})
+	}
+
+	t.Run("gaps are rejected", func(t *testing.T) {
+		require.Error(t, Chain{{From: 1}}.Validate())
+	})
+}
+
+func TestIsDecodeError(t *testing.T) {
+	_, _, err := Chain{}.Migrate([]byte(`{"a": `))
+	require.True(t, IsDecodeError(fmt.Errorf("parse config file failed: %w", err)))
+	_, _, err = Chain{}.Migrate([]byte(`{"config_version": "1"}`))
+	require.True(t, IsDecodeError(err))
+
+	_, err = os.ReadFile(filepath.Join(t.TempDir(), "missing.json"))
+	require.False(t, IsDecodeError(err))
+}
+
+func TestBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte("original"), 0644))
+
+	backup, err := Backup(path, 0)
+	require.NoError(t, err)
+	require.Equal(t, path+".v0.bak", backup)
+
+	// a second backup of the same version keeps the first one
+	require.NoError(t, os.WriteFile(path, []byte("changed"), 0644))
+	_, err = Backup(path, 0)
+	require.NoError(t, err)
+	data, err := os.ReadFile(backup)
+	require.NoError(t, err)
+	require.Equal(t, "original", string(data))
+}
+
+func TestUnknownFields(t *testing.T) {
+	type config struct {
+		Name    string `json:"name"`
+		Skipped string `json:"-"`
+		Plain   int
+	}
+
+	data := []byte(`{"name": "a", "Plain": 1, "future": {"x": [1, 2]}, "flag": true}`)
+	unknown, err := UnknownFields(data, config{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]json.RawMessage{
+		"future": json.RawMessage(`{"x": [1, 2]}`),
+		"flag":   json.RawMessage(`true`),
+	}, unknown)
+
+	encoded, err := json.Marshal(config{Name: "b", Plain: 2})
+	require.NoError(t, err)
+	merged, err := MergeUnknown(encoded, unknown)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name": "b", "Plain": 2, "future": {"x": [1, 2]}, "flag": true}`, string(merged))
+
+	t.Run("declared fields win", func(t *testing.T) {
+		merged, err := MergeUnknown([]byte(`{"name":"b"}`), map[string]json.RawMessage{"name": json.RawMessage(`"old"`)})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"name": "b"}`, string(merged))
+	})
+
+	t.Run("empty object", func(t *testing.T) {
+		merged, err := MergeUnknown([]byte(`{}`), map[string]json.RawMessage{"a": json.RawMessage(`1`)})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"a": 1}`, string(merged))
+	})
+}
@@ -0,0 +1,108 @@
+package pi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const checkboxInterface = `{
+	"interface_version": 2,
+	"name": "Checkbox",
+	"task": [{"name": "Farm", "entry": "Farm", "option": ["Stages"]}],
+	"option": {
+		"Stages": {
+			"type": "checkbox",
+			"cases": [
+				{"name": "1-7", "option": ["Times"]},
+				{"name": "2-4"},
+				{"name": "3-1", "option": ["Times"]}
+			],
+			"default_cases": ["3-1", "1-7"]
+		},
+		"Times": {"type": "input", "inputs": [{"name": "count", "default": "5"}]}
+	}
+}`
+
+func TestCaseSet(t *testing.T) {
+	opt := V2Option{Type: "checkbox", Cases: []V2OptionCase{{Name: "a"}, {Name: "b"}, {Name: "c"}}, DefaultCases: []string{"c", "x", "a"}}
+	require.Equal(t, []string{"a", "c"}, opt.DefaultSelection())
+	require.Len(t, opt.SelectedCases([]string{"c", "b"}), 2)
+	require.Equal(t, "b", opt.SelectedCases([]string{"c", "b"})[0].Name)
+	require.Empty(t, opt.SelectedCases(nil))
+
+	type Case struct {
+		value    interface{}
+		expected []string
+	}
+	for _, tc := range []Case{
+		{value: []interface{}{"a", 1, "b"}, expected: []string{"a", "b"}},
+		{value: []string{"c"}, expected: []string{"c"}},
+		{value: `["a","b"]`, expected: []string{"a", "b"}},
+		{value: "a", expected: []string{}},
+		{value: nil, expected: []string{}},
+	} {
+		require.Equal(t, tc.expected, ConfigTaskOption{Value: tc.value}.CaseNames(), "%v", tc.value)
+	}
+}
+
+func TestCheckboxOptions(t *testing.T) {
+	iface, err := ParseV2([]byte(checkboxInterface))
+	require.NoError(t, err)
+	s := &service{}
+
+	// nested options shared by several cases are kept once
+	options := s.initTaskOptions(iface.Task[0].Option, iface.Option)
+	require.Equal(t, []ConfigTaskOption{
+		{Name: "Stages", Value: []string{"1-7", "3-1"}},
+		{Name: "Times", Value: map[string]interface{}{"count": "5"}},
+	}, options)
+
+	type Case struct {
+		name     string
+		current  map[string]interface{}
+		expected []string
+	}
+
+	testCases := []Case{
+		{name: "missing uses defaults", current: map[string]interface{}{}, expected: []string{"Stages", "Times"}},
+		{name: "nothing selected", current: map[string]interface{}{"Stages": []interface{}{}}, expected: []string{"Stages"}},
+		{name: "case without options", current: map[string]interface{}{"Stages": []interface{}{"2-4"}}, expected: []string{"Stages"}},
+		{name: "case with options", current: map[string]interface{}{"Stages": []interface{}{"2-4", "3-1"}}, expected: []string{"Stages", "Times"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			names := []string{}
+			for _, opt := range getExpectedOptions(iface.Task[0].Option, tc.current, iface.Option, OptionScope{}) {
+				names = append(names, opt.Name)
+			}
+			require.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestValidateCheckbox(t *testing.T) {
+	iface, err := decodeV2([]byte(`{
+		"interface_version": 2,
+		"name": "Checkbox",
+		"task": [{"name": "Farm", "entry": "Farm", "option": ["Stages"]}],
+		"option": {
+			"Stages": {
+				"type": "checkbox",
+				"cases": [{"name": "1-7"}],
+				"default_case": "1-7",
+				"default_cases": ["1-7", "9-9"]
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	report := ValidateV2(iface)
+	require.Equal(t, []Issue{
+		{Path: "option.Stages.default_cases[1]", Severity: SeverityError, Message: "default case does not exist: 9-9"},
+	}, report.Errors())
+	require.Equal(t, []Issue{
+		{Path: "option.Stages.default_case", Severity: SeverityWarning, Message: "default_case is ignored by checkbox, use default_cases"},
+	}, report.Warnings())
+}
@@ -0,0 +1,190 @@
+package pi
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// V2Condition limits when an option or input is shown, every field that is set must match
+type V2Condition struct {
+	Option     map[string][]string `json:"option,omitempty"`     // option name -> cases, one of them must be selected
+	Controller []string            `json:"controller,omitempty"` // controller types, e.g. "Adb"
+	Resource   []string            `json:"resource,omitempty"`   // resource names
+}
+
+// option returns the option conditions, nil for a nil condition
+func (c *V2Condition) option() map[string][]string {
+	if c == nil {
+		return nil
+	}
+	return c.Option
+}
+
+// OptionScope is what the conditions of the options of a task are evaluated against
+type OptionScope struct {
+	ControllerType string
+	Resource       string
+	Values         map[string]interface{} // option values of the task, missing options are hidden
+	stored         bool                   // every condition holds, see storedScope
+}
+
+// storedScope returns the scope of the options stored in the config, in which every condition holds.
+// The config keeps the values of hidden options, so they are back when the options are shown again;
+// conditions only apply to the options shown and run.
+func storedScope() OptionScope {
+	return OptionScope{stored: true}
+}
+
+// ScopeOf returns the scope of a task of config with the option values of the task
+func ScopeOf(config *InterfaceConfig, options []ConfigTaskOption) OptionScope {
+	scope := OptionScope{
+		ControllerType: config.Controller.Type,
+		Resource:       config.Resource,
+		Values:         make(map[string]interface{}, len(options)),
+	}
+	for _, opt := range options {
+		scope.Values[opt.Name] = opt.Value
+	}
+	return scope
+}
+
+// withValues returns the scope with the option values of options
+func (s OptionScope) withValues(options []ConfigTaskOption) OptionScope {
+	s.Values = make(map[string]interface{}, len(options))
+	for _, opt := range options {
+		s.Values[opt.Name] = opt.Value
+	}
+	return s
+}
+
+// Match reports whether the condition holds in scope, a nil condition always holds.
+// An option condition holds when the option is shown and selects one of the listed cases.
+func (c *V2Condition) Match(scope OptionScope, optionDefs map[string]V2Option) bool {
+	if c == nil || scope.stored {
+		return true
+	}
+	if len(c.Controller) > 0 && !slices.Contains(c.Controller, scope.ControllerType) {
+		return false
+	}
+	if len(c.Resource) > 0 && !slices.Contains(c.Resource, scope.Resource) {
+		return false
+	}
+	for optName, cases := range c.Option {
+		value, ok := scope.Values[optName]
+		if !ok {
+			return false
+		}
+		opt := ConfigTaskOption{Name: optName, Value: value}
+		selected := []string{opt.CaseName()}
+		if def := optionDefs[optName]; def.GetType() == "checkbox" {
+			selected = opt.CaseNames()
+		}
+		if !slices.ContainsFunc(selected, func(name string) bool { return slices.Contains(cases, name) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// VisibleOptions are the options and inputs of a config task shown with its current values
+type VisibleOptions struct {
+	Options []string            `json:"options"` // in the order they are shown
+	Inputs  map[string][]string `json:"inputs"`  // input option name -> shown inputs
+}
+
+// visibleInputs returns the inputs of an input option shown in scope
+func visibleInputs(def V2Option, scope OptionScope, optionDefs map[string]V2Option) []V2OptionInput {
+	inputs := []V2OptionInput{}
+	for _, input := range def.Inputs {
+		if input.When.Match(scope, optionDefs) {
+			inputs = append(inputs, input)
+		}
+	}
+	return inputs
+}
+
+// ShownOptions returns the options of a config task shown with its values, hidden ones are left out
+func ShownOptions(iface *V2Interface, config *InterfaceConfig, task ConfigTask) []ConfigTaskOption {
+	piTask := findTask(iface, task.Name)
+	if piTask == nil {
+		return []ConfigTaskOption{}
+	}
+	scope := ScopeOf(config, task.Option)
+	return getExpectedOptions(piTask.Option, scope.Values, iface.Option, scope)
+}
+
+// validateCondition checks the names a condition refers to
+func (v *validator) validateCondition(path string, c *V2Condition, controllerTypes map[string]bool, resourceNames map[string]bool) {
+	if c == nil {
+		return
+	}
+	for _, optName := range sortedKeys(c.Option) {
+		optPath := path + ".option." + optName
+		def, ok := v.iface.Option[optName]
+		if !ok {
+			v.errorf(optPath, "reference to non-existent option: %s", optName)
+			continue
+		}
+		if def.GetType() == "input" {
+			v.errorf(optPath, "condition on input option: %s", optName)
+			continue
+		}
+		if len(c.Option[optName]) == 0 {
+			v.warnf(optPath, "no cases listed, the condition never holds")
+		}
+		for i, caseName := range c.Option[optName] {
+			if findCase(&def, caseName) == nil {
+				v.errorf(fmt.Sprintf("%s[%d]", optPath, i), "case does not exist: %s", caseName)
+			}
+		}
+	}
+	for i, ctrlType := range c.Controller {
+		if !controllerTypes[ctrlType] {
+			v.warnf(fmt.Sprintf("%s.controller[%d]", path, i), "no controller of type %s", ctrlType)
+		}
+	}
+	for i, resName := range c.Resource {
+		if !resourceNames[resName] {
+			v.errorf(fmt.Sprintf("%s.resource[%d]", path, i), "reference to non-existent resource: %s", resName)
+		}
+	}
+}
+
+// ==================== frontend exposed interfaces ====================
+
+// GetVisibleOptions gets the options and inputs of a config task shown with its current values,
+// the conditions of the options are evaluated against the selected controller and resource
+func (s *service) GetVisibleOptions(id string) (*VisibleOptions, error) {
+	v2Loaded := s.V2Loaded()
+	if v2Loaded == nil || v2Loaded.Interface == nil {
+		return nil, errors.New("interface not loaded")
+	}
+	iface := v2Loaded.Interface
+
+	s.configMu.RLock()
+	config := s.currentConfig()
+	i, err := taskIndex(config, id)
+	if err != nil {
+		s.configMu.RUnlock()
+		return nil, err
+	}
+	expected := ShownOptions(iface, config, config.Task[i])
+	scope := ScopeOf(config, expected)
+	s.configMu.RUnlock()
+
+	visible := &VisibleOptions{Options: []string{}, Inputs: map[string][]string{}}
+	for _, opt := range expected {
+		visible.Options = append(visible.Options, opt.Name)
+		def := iface.Option[opt.Name]
+		if def.GetType() != "input" {
+			continue
+		}
+		names := []string{}
+		for _, input := range visibleInputs(def, scope, iface.Option) {
+			names = append(names, input.Name)
+		}
+		visible.Inputs[opt.Name] = names
+	}
+	return visible, nil
+}
@@ -0,0 +1,156 @@
+package pi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const conditionInterface = `{
+	"interface_version": 2,
+	"name": "Conditions",
+	"controller": [{"name": "Phone", "type": "Adb"}, {"name": "Desktop", "type": "Win32"}],
+	"resource": [{"name": "Official", "path": ["res"]}, {"name": "Global", "path": ["global"]}],
+	"task": [{"name": "A", "entry": "A", "default_check": true, "option": ["Times", "Mode", "Touch"]}],
+	"option": {
+		"Mode": {"cases": [{"name": "Fast"}, {"name": "Custom"}]},
+		"Times": {
+			"type": "input",
+			"when": {"option": {"Mode": ["Custom"]}},
+			"inputs": [
+				{"name": "count", "pipeline_type": "int"},
+				{"name": "server", "when": {"resource": ["Global"]}}
+			]
+		},
+		"Touch": {"type": "switch", "when": {"controller": ["Adb"]}, "cases": [{"name": "Yes"}, {"name": "No"}]}
+	}
+}`
+
+func TestConditions(t *testing.T) {
+	iface, err := ParseV2([]byte(conditionInterface))
+	require.NoError(t, err)
+
+	type Case struct {
+		name     string
+		scope    OptionScope
+		current  map[string]interface{}
+		expected []ConfigTaskOption
+	}
+
+	testCases := []Case{
+		{
+			name:     "defaults",
+			scope:    OptionScope{ControllerType: "Adb", Resource: "Official"},
+			current:  map[string]interface{}{},
+			expected: []ConfigTaskOption{{Name: "Mode", Value: "Fast"}, {Name: "Touch", Value: "No"}},
+		},
+		{
+			// Times comes before Mode in the task, it is shown once Mode is known
+			name:    "option condition",
+			scope:   OptionScope{ControllerType: "Adb", Resource: "Official"},
+			current: map[string]interface{}{"Mode": "Custom", "Touch": "Yes"},
+			expected: []ConfigTaskOption{
+				{Name: "Times", Value: map[string]interface{}{"count": 0}},
+				{Name: "Mode", Value: "Custom"},
+				{Name: "Touch", Value: "Yes"},
+			},
+		},
+		{
+			name:    "controller and resource conditions",
+			scope:   OptionScope{ControllerType: "Win32", Resource: "Global"},
+			current: map[string]interface{}{"Mode": "Custom", "Touch": "Yes", "Times": map[string]interface{}{"count": 3.0}},
+			expected: []ConfigTaskOption{
+				{Name: "Times", Value: map[string]interface{}{"count": 3, "server": ""}},
+				{Name: "Mode", Value: "Custom"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, getExpectedOptions(iface.Task[0].Option, tc.current, iface.Option, tc.scope))
+		})
+	}
+}
+
+func TestVisibleOptions(t *testing.T) {
+	s, _ := openTestProject(t, conditionInterface)
+
+	id := s.GetConfig().Task[0].ID
+	visible, err := s.GetVisibleOptions(id)
+	require.NoError(t, err)
+	require.Equal(t, &VisibleOptions{Options: []string{"Mode", "Touch"}, Inputs: map[string][]string{}}, visible)
+
+	revision, err := s.SetTaskOption(s.GetRevision(), id, "Touch", "Yes")
+	require.NoError(t, err)
+	revision, err = s.SetTaskOption(revision, id, "Mode", "Custom")
+	require.NoError(t, err)
+	// the server input is only shown for the Global resource
+	_, err = s.SetTaskOption(revision, id, "Times", map[string]interface{}{"server": "eu"})
+	require.Error(t, err)
+
+	revision, err = s.SetController(revision, "Desktop")
+	require.NoError(t, err)
+	_, err = s.SetResource(revision, "Global")
+	require.NoError(t, err)
+
+	visible, err = s.GetVisibleOptions(id)
+	require.NoError(t, err)
+	require.Equal(t, &VisibleOptions{
+		Options: []string{"Times", "Mode"},
+		Inputs:  map[string][]string{"Times": {"count", "server"}},
+	}, visible)
+	// options hidden by the controller keep their values in the config, but are not run
+	config := s.GetConfig()
+	require.Equal(t, []string{"Times", "Mode", "Touch"}, optionNames(config.Task[0].Option))
+	require.Equal(t, "Yes", config.Task[0].Option[2].Value)
+	require.Equal(t, []string{"Times", "Mode"}, optionNames(ShownOptions(s.V2Loaded().Interface, config, config.Task[0])))
+
+	// and are back when they are shown again
+	_, err = s.SetController(s.GetRevision(), "Phone")
+	require.NoError(t, err)
+	visible, err = s.GetVisibleOptions(id)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Times", "Mode", "Touch"}, visible.Options)
+	shown := ShownOptions(s.V2Loaded().Interface, s.GetConfig(), s.GetConfig().Task[0])
+	require.Equal(t, ConfigTaskOption{Name: "Touch", Value: "Yes"}, shown[2])
+}
+
+func TestValidateConditions(t *testing.T) {
+	iface, err := decodeV2([]byte(`{
+		"interface_version": 2,
+		"name": "Conditions",
+		"controller": [{"name": "Phone", "type": "Adb"}],
+		"resource": [{"name": "Official", "path": ["res"]}],
+		"task": [{"name": "A", "entry": "A", "option": ["Mode", "Times"]}],
+		"option": {
+			"Mode": {"cases": [{"name": "Fast"}], "when": {"option": {"Mode": ["Fast"]}}},
+			"Times": {
+				"type": "input",
+				"when": {"option": {"Missing": ["x"], "Mode": ["Slow"]}, "controller": ["Win32"]},
+				"inputs": [{"name": "count", "when": {"option": {"Times": ["x"]}, "resource": ["Global"]}}]
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	report := ValidateV2(iface)
+	require.Equal(t, []Issue{
+		{Path: "option.Mode.when.option.Mode", Severity: SeverityError, Message: "condition on the option itself, it is never shown"},
+		{Path: "option.Times.when.option.Missing", Severity: SeverityError, Message: "reference to non-existent option: Missing"},
+		{Path: "option.Times.when.option.Mode[0]", Severity: SeverityError, Message: "case does not exist: Slow"},
+		{Path: "option.Times.inputs[0].when.option.Times", Severity: SeverityError, Message: "condition on input option: Times"},
+		{Path: "option.Times.inputs[0].when.resource[0]", Severity: SeverityError, Message: "reference to non-existent resource: Global"},
+	}, report.Errors())
+	require.Equal(t, []Issue{
+		{Path: "option.Times.when.controller[0]", Severity: SeverityWarning, Message: "no controller of type Win32"},
+	}, report.Warnings())
+}
+
+func optionNames(options []ConfigTaskOption) []string {
+	names := []string{}
+	for _, opt := range options {
+		names = append(names, opt.Name)
+	}
+	return names
+}
@@ -1,9 +1,14 @@
 package pi
 
+import (
+	"encoding/json"
+	"muu-alpha/backend/migrate"
+)
+
 // ConfigController controller config
 type ConfigController struct {
 	Name string `json:"name"`
-	Type string `json:"type"` // "Adb" | "Win32"
+	Type string `json:"type" jsonschema:"enum=Adb|Win32"`
 }
 
 // ConfigAdb adb config
@@ -18,10 +23,11 @@ type ConfigWin32 struct {
 	// todo
 }
 
-// ConfigTaskOption task option
+// ConfigTaskOption task option. Value is the case name of a select or switch,
+// the list of case names of a checkbox, or an object of input name to typed value
 type ConfigTaskOption struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
 }
 
 // ConfigTask task config
@@ -34,9 +40,48 @@ type ConfigTask struct {
 
 // InterfaceConfig interface config
 type InterfaceConfig struct {
-	Controller ConfigController `json:"controller"`
-	Adb        *ConfigAdb       `json:"adb,omitempty"`
-	Win32      *ConfigWin32     `json:"win32,omitempty"`
-	Resource   string           `json:"resource"`
-	Task       []ConfigTask     `json:"task"`
+	ConfigVersion int              `json:"config_version,omitempty"`
+	Controller    ConfigController `json:"controller"`
+	Adb           *ConfigAdb       `json:"adb,omitempty"`
+	Win32         *ConfigWin32     `json:"win32,omitempty"`
+	Resource      string           `json:"resource"`
+	Task          []ConfigTask     `json:"task"`
+	// KnownTasks are the interface tasks the config was last synced with,
+	// default_check tasks added to the interface later are appended to Task
+	KnownTasks []string `json:"known_tasks,omitempty"`
+
+	// Extra holds the fields this version does not know, they are written back unchanged
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// configMigrations upgrades interface_config.json, the index of a migration is the version it upgrades from
+var configMigrations = migrate.Chain{
+	{From: 0, Description: "add config_version", Apply: func(doc migrate.Doc) error { return nil }},
+	{From: 1, Description: "typed option values", Apply: migrateOptionValues},
+}
+
+// ConfigVersion is the version of interface_config.json written by this build,
+// it must match the migrations, which is checked by the tests
+const ConfigVersion = 2
+
+func (c *InterfaceConfig) UnmarshalJSON(data []byte) error {
+	type plain InterfaceConfig
+	if err := json.Unmarshal(data, (*plain)(c)); err != nil {
+		return err
+	}
+	extra, err := migrate.UnknownFields(data, plain{})
+	if err != nil {
+		return err
+	}
+	c.Extra = extra
+	return nil
+}
+
+func (c InterfaceConfig) MarshalJSON() ([]byte, error) {
+	type plain InterfaceConfig
+	data, err := json.Marshal(plain(c))
+	if err != nil {
+		return nil, err
+	}
+	return migrate.MergeUnknown(data, c.Extra)
 }
@@ -0,0 +1,223 @@
+package pi
+
+import (
+	"encoding/json"
+	"muu-alpha/backend/events"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigMigrations(t *testing.T) {
+	require.NoError(t, configMigrations.Validate())
+	require.Equal(t, configMigrations.Latest(), ConfigVersion)
+}
+
+func TestReadConfig(t *testing.T) {
+	t.Run("unversioned config is migrated with a backup", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "interface_config.json")
+		original := `{"controller": {"name": "Emulator", "type": "Adb"}, "resource": "Official", "task": [], "theme_color": "red"}`
+		require.NoError(t, os.WriteFile(path, []byte(original), 0644))
+
+		config, from, err := readConfig(path)
+		require.NoError(t, err)
+		require.Equal(t, 0, from)
+		require.Equal(t, ConfigVersion, config.ConfigVersion)
+		require.Equal(t, json.RawMessage(`"red"`), config.Extra["theme_color"])
+
+		// reading alone leaves the file as is
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, original, string(data))
+		require.NoFileExists(t, path+".v0.bak")
+
+		require.NoError(t, persistMigration(path, config, from))
+		backup, err := os.ReadFile(path + ".v0.bak")
+		require.NoError(t, err)
+		require.Equal(t, original, string(backup))
+
+		// the rewritten file keeps the unknown field
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &doc))
+		require.Equal(t, "red", doc["theme_color"])
+		require.Equal(t, float64(ConfigVersion), doc["config_version"])
+	})
+
+	t.Run("newer config is loaded as is", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "interface_config.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"config_version": 99, "controller": {"name": "", "type": ""}, "resource": "", "task": []}`), 0644))
+
+		config, from, err := readConfig(path)
+		require.NoError(t, err)
+		require.Equal(t, 99, config.ConfigVersion)
+		require.NoError(t, persistMigration(path, config, from))
+
+		require.NoError(t, writeConfig(path, config))
+		config, _, err = readConfig(path)
+		require.NoError(t, err)
+		require.Equal(t, 99, config.ConfigVersion)
+	})
+}
+
+func TestInterfaceConfigRoundTrip(t *testing.T) {
+	data := []byte(`{"config_version": 1, "controller": {"name": "A", "type": "Adb"}, "resource": "R", "task": [], "future": [1]}`)
+
+	var config InterfaceConfig
+	require.NoError(t, json.Unmarshal(data, &config))
+	out, err := json.MarshalIndent(config, "", "  ")
+	require.NoError(t, err)
+	require.JSONEq(t, string(data), string(out))
+}
+
+func TestLoadRecoversConfig(t *testing.T) {
+	dir := t.TempDir()
+	ifacePath := writeProject(t, dir, "Recover")
+	configPath := filepath.Join(dir, "interface_config.json")
+
+	recovered := []events.ConfigRecovered{}
+	unsubscribe := events.Subscribe(func(e events.Event) {
+		if e, ok := e.(events.ConfigRecovered); ok {
+			recovered = append(recovered, e)
+		}
+	})
+	defer unsubscribe()
+
+	// two saves leave the first content in a backup
+	s := newTestService(t)
+	require.NoError(t, s.load(ifacePath, configPath))
+	id := s.GetConfig().Task[0].ID
+	revision, err := s.SetTaskChecked(s.GetRevision(), id, true)
+	require.NoError(t, err)
+	_, err = s.SetTaskChecked(revision, id, false)
+	require.NoError(t, err)
+
+	t.Run("corrupt config is restored from the newest valid backup", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(configPath, []byte(`{"task": [`), 0644))
+		require.NoError(t, s.load(ifacePath, configPath))
+
+		require.True(t, s.GetConfig().Task[0].Checked)
+		require.Equal(t, 1, len(recovered))
+		require.Equal(t, configPath, recovered[0].Path)
+		require.NotEmpty(t, recovered[0].Backup)
+
+		corrupt, err := os.ReadFile(recovered[0].Corrupt)
+		require.NoError(t, err)
+		require.Equal(t, `{"task": [`, string(corrupt))
+	})
+
+	t.Run("defaults without a valid backup", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, "backups")))
+		require.NoError(t, os.WriteFile(configPath, []byte(`broken`), 0644))
+		require.NoError(t, s.load(ifacePath, configPath))
+
+		require.False(t, s.GetConfig().Task[0].Checked)
+		require.Equal(t, 2, len(recovered))
+		require.Empty(t, recovered[1].Backup)
+	})
+
+	t.Run("unreadable config is not replaced", func(t *testing.T) {
+		require.NoError(t, os.Remove(configPath))
+		require.NoError(t, os.Mkdir(configPath, 0755))
+		defer os.Remove(configPath)
+
+		require.Error(t, s.load(ifacePath, configPath))
+		require.Equal(t, 2, len(recovered))
+		info, err := os.Stat(configPath)
+		require.NoError(t, err)
+		require.True(t, info.IsDir())
+	})
+}
+
+func TestMigrateOptionValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "interface_config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"config_version": 1,
+		"controller": {"name": "Phone", "type": "Adb"},
+		"resource": "Official",
+		"task": [{"id": "1", "name": "A", "checked": true, "option": [
+			{"name": "Mode", "value": "Custom"},
+			{"name": "Times.count", "value": "12"},
+			{"name": "Stages", "value": "[\"1-7\",\"3-1\"]"}
+		]}]
+	}`), 0644))
+
+	config, _, err := readConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, ConfigVersion, config.ConfigVersion)
+	require.Equal(t, []ConfigTaskOption{
+		{Name: "Mode", Value: "Custom"},
+		{Name: "Times.count", Value: "12"},
+		{Name: "Stages", Value: []interface{}{"1-7", "3-1"}},
+	}, config.Task[0].Option)
+
+	// the sync groups the inputs and converts the values to the types of the interface
+	iface, err := ParseV2([]byte(mutationInterface))
+	require.NoError(t, err)
+	s := &service{}
+	report := newConfigSync()
+	require.True(t, s.syncTaskOptions(config, iface, &report))
+	require.Equal(t, []ConfigTaskOption{
+		{Name: "Mode", Value: "Custom"},
+		{Name: "Times", Value: map[string]interface{}{"count": 12}},
+	}, config.Task[0].Option)
+	require.False(t, s.syncTaskOptions(config, iface, &report))
+}
+
+func TestGroupInputValues(t *testing.T) {
+	optionDefs := map[string]V2Option{
+		"Stage.Select": {Type: "select", Cases: []V2OptionCase{{Name: "1-7"}, {Name: "2-4"}}},
+		"Times":        {Type: "input", Inputs: []V2OptionInput{{Name: "count"}, {Name: "delay.ms"}}},
+		"Farm.Server":  {Type: "input", Inputs: []V2OptionInput{{Name: "region"}}},
+	}
+
+	type Case struct {
+		name     string
+		options  []ConfigTaskOption
+		expected []ConfigTaskOption
+		changed  bool
+	}
+
+	testCases := []Case{
+		{
+			name:     "select with a dot in its name",
+			options:  []ConfigTaskOption{{Name: "Stage.Select", Value: "1-7"}},
+			expected: []ConfigTaskOption{{Name: "Stage.Select", Value: "1-7"}},
+		},
+		{
+			name: "inputs are grouped in place",
+			options: []ConfigTaskOption{
+				{Name: "Times.count", Value: "12"},
+				{Name: "Stage.Select", Value: "2-4"},
+				{Name: "Times.delay.ms", Value: "500"},
+			},
+			expected: []ConfigTaskOption{
+				{Name: "Times", Value: map[string]interface{}{"count": "12", "delay.ms": "500"}},
+				{Name: "Stage.Select", Value: "2-4"},
+			},
+			changed: true,
+		},
+		{
+			name:     "input option with a dot in its name",
+			options:  []ConfigTaskOption{{Name: "Farm.Server.region", Value: "CN"}},
+			expected: []ConfigTaskOption{{Name: "Farm.Server", Value: map[string]interface{}{"region": "CN"}}},
+			changed:  true,
+		},
+		{
+			name:     "unknown names are kept",
+			options:  []ConfigTaskOption{{Name: "Times.unknown", Value: "1"}},
+			expected: []ConfigTaskOption{{Name: "Times.unknown", Value: "1"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			grouped, changed := groupInputValues(tc.options, optionDefs)
+			require.Equal(t, tc.expected, grouped)
+			require.Equal(t, tc.changed, changed)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package pi
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// KeyCoverage is an i18n key used by the interface
+type KeyCoverage struct {
+	Key     string   `json:"key"`
+	Paths   []string `json:"paths"`   // where the key is used, e.g. "task[0].label"
+	Missing []string `json:"missing"` // languages whose translation file lacks the key
+}
+
+// TranslationFile is the coverage of one translation file
+type TranslationFile struct {
+	Language string   `json:"language"`
+	Path     string   `json:"path"` // as declared in the interface
+	Keys     int      `json:"keys"`
+	Unused   []string `json:"unused"` // keys the interface never uses
+	Error    string   `json:"error,omitempty"`
+}
+
+// CoverageReport is the translation coverage of an interface
+type CoverageReport struct {
+	Keys  []KeyCoverage     `json:"keys"`
+	Files []TranslationFile `json:"files"`
+}
+
+// Complete reports whether every key is translated in every language and every file loaded
+func (r *CoverageReport) Complete() bool {
+	for _, key := range r.Keys {
+		if len(key.Missing) > 0 {
+			return false
+		}
+	}
+	for _, file := range r.Files {
+		if file.Error != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Issues converts the report into lint issues: failed files are errors,
+// missing and unused keys are warnings
+func (r *CoverageReport) Issues() []Issue {
+	issues := []Issue{}
+	for _, file := range r.Files {
+		path := "languages." + file.Language
+		if file.Error != "" {
+			issues = append(issues, Issue{Path: path, Severity: SeverityError, Message: file.Error})
+			continue
+		}
+		for _, key := range file.Unused {
+			issues = append(issues, Issue{Path: path, Severity: SeverityWarning, Message: "unused translation key: " + key})
+		}
+	}
+	for _, key := range r.Keys {
+		if len(key.Missing) == 0 {
+			continue
+		}
+		issues = append(issues, Issue{
+			Path:     key.Paths[0],
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("translation $%s missing in: %s", key.Key, strings.Join(key.Missing, ", ")),
+		})
+	}
+	return issues
+}
+
+// AnalyzeCoverage checks the translation files of iface, resolved against basePath.
+// Languages whose file failed to load are reported in Files and not counted as missing keys.
+func AnalyzeCoverage(iface *V2Interface, basePath string) *CoverageReport {
+	report := &CoverageReport{
+		Keys:  []KeyCoverage{},
+		Files: []TranslationFile{},
+	}
+
+	index := make(map[string]int)
+	visitStrings(iface, func(path string, s *string, doc bool) {
+		if !IsI18nString(*s) {
+			return
+		}
+		key := GetI18nKey(*s)
+		i, ok := index[key]
+		if !ok {
+			i = len(report.Keys)
+			index[key] = i
+			report.Keys = append(report.Keys, KeyCoverage{Key: key, Paths: []string{}, Missing: []string{}})
+		}
+		report.Keys[i].Paths = append(report.Keys[i].Paths, path)
+	})
+
+	for _, lang := range sortedKeys(iface.Languages) {
+		file := TranslationFile{
+			Language: lang,
+			Path:     iface.Languages[lang],
+			Unused:   []string{},
+		}
+
+		translations, err := readTranslations(filepath.Join(basePath, file.Path))
+		if err != nil {
+			file.Error = err.Error()
+			report.Files = append(report.Files, file)
+			continue
+		}
+		file.Keys = len(translations)
+
+		for i := range report.Keys {
+			if _, ok := translations[report.Keys[i].Key]; !ok {
+				report.Keys[i].Missing = append(report.Keys[i].Missing, lang)
+			}
+		}
+		for _, key := range sortedKeys(translations) {
+			if _, ok := index[key]; !ok {
+				file.Unused = append(file.Unused, key)
+			}
+		}
+		report.Files = append(report.Files, file)
+	}
+
+	sort.SliceStable(report.Keys, func(i, j int) bool {
+		return report.Keys[i].Key < report.Keys[j].Key
+	})
+	return report
+}
+
+// CoverageFile analyzes the translation coverage of the interface file at path.
+// v1 interfaces have no translations and get an empty report.
+func CoverageFile(path string) (*CoverageReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file failed: %w", err)
+	}
+
+	iface := &V2Interface{}
+	if version, err := DetectVersion(data); err == nil && version == Version2 {
+		if iface, err = decodeV2(data); err != nil {
+			return nil, err
+		}
+		if err := resolveImports(iface, path); err != nil {
+			return nil, err
+		}
+	}
+	return AnalyzeCoverage(iface, filepath.Dir(path)), nil
+}
+
+// ==================== frontend exposed interfaces ====================
+
+// GetTranslationCoverage gets the translation coverage of the active project
+func (s *service) GetTranslationCoverage() (*CoverageReport, error) {
+	v2Loaded := s.V2Loaded()
+	if v2Loaded == nil || v2Loaded.Interface == nil {
+		return nil, errors.New("interface not loaded")
+	}
+	return AnalyzeCoverage(v2Loaded.Interface, v2Loaded.BasePath), nil
+}
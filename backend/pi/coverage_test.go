@@ -0,0 +1,58 @@
+package pi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeCoverage(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"interface.json": `{
+			"interface_version": 2,
+			"name": "Test",
+			"label": "$title",
+			"languages": {"zh-CN": "zh_cn.json", "en-US": "en_us.json", "ja-JP": "ja_jp.json"},
+			"task": [{"name": "A", "entry": "A", "label": "$task"}, {"name": "B", "entry": "B", "label": "$task"}],
+			"option": {"Mode": {"cases": [{"name": "X", "label": "$case"}]}}
+		}`,
+		"zh_cn.json": `{"title": "测试", "task": "任务", "case": "选项", "old": "旧"}`,
+		"en_us.json": `{"title": "Test"}`,
+		"ja_jp.json": `{invalid`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	report, err := CoverageFile(filepath.Join(dir, "interface.json"))
+	require.NoError(t, err)
+	require.False(t, report.Complete())
+
+	require.Equal(t, []KeyCoverage{
+		{Key: "case", Paths: []string{"option.Mode.cases[0].label"}, Missing: []string{"en-US"}},
+		{Key: "task", Paths: []string{"task[0].label", "task[1].label"}, Missing: []string{"en-US"}},
+		{Key: "title", Paths: []string{"label"}, Missing: []string{}},
+	}, report.Keys)
+
+	require.Equal(t, 3, len(report.Files))
+	require.Equal(t, "en-US", report.Files[0].Language)
+	require.Equal(t, []string{}, report.Files[0].Unused)
+	require.Equal(t, "ja-JP", report.Files[1].Language)
+	require.NotEmpty(t, report.Files[1].Error)
+	require.Equal(t, "zh-CN", report.Files[2].Language)
+	require.Equal(t, []string{"old"}, report.Files[2].Unused)
+
+	paths := []string{}
+	for _, issue := range report.Issues() {
+		paths = append(paths, string(issue.Severity)+" "+issue.Path)
+	}
+	require.Equal(t, []string{
+		"error languages.ja-JP",
+		"warning languages.zh-CN",
+		"warning option.Mode.cases[0].label",
+		"warning task[0].label",
+	}, paths)
+}
@@ -0,0 +1,97 @@
+package pi
+
+import (
+	"encoding/json"
+	"muu-alpha/backend/events"
+	"reflect"
+	"sort"
+)
+
+// diffInterfaces summarizes the differences between two loaded interfaces
+func diffInterfaces(old, new *V2Loaded) events.InterfaceReloaded {
+	var oldIface, newIface V2Interface
+	if old != nil && old.Interface != nil {
+		oldIface = *old.Interface
+	}
+	if new != nil && new.Interface != nil {
+		newIface = *new.Interface
+	}
+
+	return events.InterfaceReloaded{
+		Controllers: diffNamed(
+			byName(oldIface.Controller, func(c V2Controller) string { return c.Name }),
+			byName(newIface.Controller, func(c V2Controller) string { return c.Name }),
+		),
+		Resources: diffNamed(
+			byName(oldIface.Resource, func(r V2Resource) string { return r.Name }),
+			byName(newIface.Resource, func(r V2Resource) string { return r.Name }),
+		),
+		Tasks: diffNamed(
+			byName(oldIface.Task, func(t V2Task) string { return t.Name }),
+			byName(newIface.Task, func(t V2Task) string { return t.Name }),
+		),
+		Options:   diffNamed(oldIface.Option, newIface.Option),
+		Languages: diffNamed(translationsOf(old), translationsOf(new)),
+	}
+}
+
+// byName indexes a list of named entries
+func byName[T any](items []T, name func(T) string) map[string]T {
+	m := make(map[string]T, len(items))
+	for _, item := range items {
+		m[name(item)] = item
+	}
+	return m
+}
+
+// translationsOf returns the translations of every loaded language
+func translationsOf(loaded *V2Loaded) map[string]map[string]string {
+	m := make(map[string]map[string]string)
+	if loaded == nil {
+		return m
+	}
+	for lang, resolver := range loaded.Resolvers {
+		m[lang] = resolver.translations
+	}
+	return m
+}
+
+// diffNamed compares two maps of named entries, entries are compared by their JSON form
+func diffNamed[T any](old, new map[string]T) events.NameDiff {
+	diff := events.NameDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{},
+	}
+
+	for name, newItem := range new {
+		oldItem, ok := old[name]
+		if !ok {
+			diff.Added = append(diff.Added, name)
+			continue
+		}
+		if !sameJSON(oldItem, newItem) {
+			diff.Changed = append(diff.Changed, name)
+		}
+	}
+	for name := range old {
+		if _, ok := new[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// sameJSON reports whether a and b serialize to the same JSON
+func sameJSON(a, b interface{}) bool {
+	aData, errA := json.Marshal(a)
+	bData, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(aData) == string(bData)
+}
@@ -0,0 +1,148 @@
+package pi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// optionEdge is a reference from a case of an option to a nested option
+type optionEdge struct {
+	caseIndex   int
+	optionIndex int
+	caseName    string
+	target      string
+}
+
+// optionEdges returns the references from the cases of an option to existing options
+func optionEdges(iface *V2Interface, name string) []optionEdge {
+	edges := []optionEdge{}
+	for i, c := range iface.Option[name].Cases {
+		for j, target := range c.Option {
+			if _, ok := iface.Option[target]; ok {
+				edges = append(edges, optionEdge{
+					caseIndex:   i,
+					optionIndex: j,
+					caseName:    c.Name,
+					target:      target,
+				})
+			}
+		}
+	}
+	return edges
+}
+
+// validateOptionGraph reports cycles between options, which would recurse forever when
+// collecting defaults or overrides, and options that no task can reach
+func (v *validator) validateOptionGraph() {
+	v.validateOptionCycles()
+	v.validateOptionReachability()
+}
+
+// validateOptionCycles reports every cycle once, with the full path through the cases
+func (v *validator) validateOptionCycles() {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	state := make(map[string]int)
+	reported := make(map[string]bool)
+
+	// stack holds the options being visited and the edge taken out of each of them
+	type frame struct {
+		name string
+		edge optionEdge
+	}
+	var stack []frame
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		for _, edge := range optionEdges(v.iface, name) {
+			stack = append(stack, frame{name: name, edge: edge})
+
+			switch state[edge.target] {
+			case unvisited:
+				visit(edge.target)
+			case visiting:
+				// the cycle starts where the target was entered
+				start := len(stack) - 1
+				for start > 0 && stack[start].name != edge.target {
+					start--
+				}
+				cycle := stack[start:]
+
+				names := make([]string, 0, len(cycle))
+				steps := make([]string, 0, len(cycle)+1)
+				for _, f := range cycle {
+					names = append(names, f.name)
+					steps = append(steps, fmt.Sprintf("%s[%s]", f.name, f.edge.caseName))
+				}
+				steps = append(steps, edge.target)
+
+				key := cycleKey(names)
+				if !reported[key] {
+					reported[key] = true
+					path := fmt.Sprintf("option.%s.cases[%d].option[%d]", name, edge.caseIndex, edge.optionIndex)
+					v.errorf(path, "option cycle: %s", strings.Join(steps, " -> "))
+				}
+			}
+
+			stack = stack[:len(stack)-1]
+		}
+		state[name] = done
+	}
+
+	for _, name := range sortedKeys(v.iface.Option) {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+}
+
+// cycleKey identifies a cycle regardless of the option it was entered from
+func cycleKey(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	min := 0
+	for i := range names {
+		if names[i] < names[min] {
+			min = i
+		}
+	}
+	rotated := append(append([]string{}, names[min:]...), names[:min]...)
+	return strings.Join(rotated, "\x00")
+}
+
+// validateOptionReachability warns about options that no task references, directly or through cases
+func (v *validator) validateOptionReachability() {
+	reachable := make(map[string]bool)
+	queue := []string{}
+	for _, task := range v.iface.Task {
+		for _, name := range task.Option {
+			if _, ok := v.iface.Option[name]; ok && !reachable[name] {
+				reachable[name] = true
+				queue = append(queue, name)
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, edge := range optionEdges(v.iface, name) {
+			if !reachable[edge.target] {
+				reachable[edge.target] = true
+				queue = append(queue, edge.target)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(v.iface.Option) {
+		if !reachable[name] {
+			v.warnf("option."+name, "option is not reachable from any task")
+		}
+	}
+}
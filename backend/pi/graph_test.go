@@ -0,0 +1,104 @@
+package pi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateOptionGraph(t *testing.T) {
+	t.Run("cycle is reported once with its path", func(t *testing.T) {
+		data := `{
+			"interface_version": 2,
+			"name": "Test",
+			"task": [{"name": "Task", "entry": "Task", "option": ["A"]}],
+			"option": {
+				"A": {"cases": [{"name": "x"}, {"name": "toB", "option": ["B"]}]},
+				"B": {"cases": [{"name": "toA", "option": ["A"]}]}
+			}
+		}`
+		iface, err := decodeV2([]byte(data))
+		require.NoError(t, err)
+
+		errs := ValidateV2(iface).Errors()
+		require.Equal(t, 1, len(errs))
+		require.Equal(t, "option.B.cases[0].option[0]", errs[0].Path)
+		require.Equal(t, "option cycle: A[toB] -> B[toA] -> A", errs[0].Message)
+
+		_, err = ParseV2([]byte(data))
+		require.Error(t, err)
+	})
+
+	t.Run("self reference", func(t *testing.T) {
+		data := `{
+			"interface_version": 2,
+			"name": "Test",
+			"task": [{"name": "Task", "entry": "Task", "option": ["A"]}],
+			"option": {
+				"A": {"cases": [{"name": "again", "option": ["A"]}]}
+			}
+		}`
+		iface, err := decodeV2([]byte(data))
+		require.NoError(t, err)
+
+		errs := ValidateV2(iface).Errors()
+		require.Equal(t, 1, len(errs))
+		require.Equal(t, "option cycle: A[again] -> A", errs[0].Message)
+	})
+
+	t.Run("shared nested option is not a cycle", func(t *testing.T) {
+		data := `{
+			"interface_version": 2,
+			"name": "Test",
+			"task": [{"name": "Task", "entry": "Task", "option": ["A", "B"]}],
+			"option": {
+				"A": {"cases": [{"name": "x", "option": ["C"]}]},
+				"B": {"cases": [{"name": "y", "option": ["C"]}]},
+				"C": {"cases": [{"name": "z"}]}
+			}
+		}`
+		iface, err := decodeV2([]byte(data))
+		require.NoError(t, err)
+		require.Empty(t, ValidateV2(iface).Issues)
+	})
+
+	t.Run("unreachable options", func(t *testing.T) {
+		data := `{
+			"interface_version": 2,
+			"name": "Test",
+			"task": [{"name": "Task", "entry": "Task", "option": ["A"]}],
+			"option": {
+				"A": {"cases": [{"name": "x", "option": ["B"]}]},
+				"B": {"cases": [{"name": "y"}]},
+				"Orphan": {"cases": [{"name": "z", "option": ["OrphanChild"]}]},
+				"OrphanChild": {"cases": [{"name": "w"}]}
+			}
+		}`
+		iface, err := decodeV2([]byte(data))
+		require.NoError(t, err)
+
+		warnings := ValidateV2(iface).Warnings()
+		paths := []string{}
+		for _, w := range warnings {
+			paths = append(paths, w.Path)
+		}
+		require.Equal(t, []string{"option.Orphan", "option.OrphanChild"}, paths)
+	})
+
+	t.Run("duplicate case names", func(t *testing.T) {
+		data := `{
+			"interface_version": 2,
+			"name": "Test",
+			"task": [{"name": "Task", "entry": "Task", "option": ["A"]}],
+			"option": {
+				"A": {"cases": [{"name": "x"}, {"name": "x"}]}
+			}
+		}`
+		iface, err := decodeV2([]byte(data))
+		require.NoError(t, err)
+
+		errs := ValidateV2(iface).Errors()
+		require.Equal(t, 1, len(errs))
+		require.Equal(t, "option.A.cases[1].name", errs[0].Path)
+	})
+}
@@ -0,0 +1,259 @@
+package pi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultFallbackLanguage is tried after the requested language and its regional variants
+const DefaultFallbackLanguage = "en-US"
+
+// LocalizedInterface is the interface with every i18n string resolved for one language
+type LocalizedInterface struct {
+	Language  string       `json:"language"`
+	Chain     []string     `json:"chain"` // languages tried in order before falling back to the key
+	Interface *V2Interface `json:"interface"`
+}
+
+// FallbackChain returns the languages tried when resolving strings for lang.
+// A chain configured for lang is used as is after lang itself, otherwise lang is followed by
+// the other variants of the same language (e.g. zh-TW -> zh-CN) and DefaultFallbackLanguage.
+func (l *V2Loaded) FallbackChain(lang string, configured map[string][]string) []string {
+	if lang == "" {
+		lang = DefaultFallbackLanguage
+	}
+
+	chain := []string{lang}
+	seen := map[string]bool{lang: true}
+	add := func(langs ...string) {
+		for _, fallback := range langs {
+			if fallback != "" && !seen[fallback] {
+				seen[fallback] = true
+				chain = append(chain, fallback)
+			}
+		}
+	}
+
+	if fallback, ok := configured[lang]; ok {
+		add(fallback...)
+		return chain
+	}
+
+	primary := primaryLanguage(lang)
+	variants := []string{}
+	for _, variant := range l.GetLanguages() {
+		if primaryLanguage(variant) == primary {
+			variants = append(variants, variant)
+		}
+	}
+	sort.Strings(variants)
+	add(variants...)
+	add(DefaultFallbackLanguage)
+	return chain
+}
+
+// primaryLanguage returns the language subtag of a language tag, e.g. "zh" for "zh-TW"
+func primaryLanguage(lang string) string {
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		return lang[:i]
+	}
+	return lang
+}
+
+// maxContentReads is how many documents are read at once while localizing
+const maxContentReads = 8
+
+// localize returns a copy of iface with the display strings resolved by resolve,
+// descriptions and the welcome message are also read through content
+func localize(iface *V2Interface, resolve func(string) string, content func(string) string) (*V2Interface, error) {
+	data, err := json.Marshal(iface)
+	if err != nil {
+		return nil, fmt.Errorf("copy interface failed: %w", err)
+	}
+	var out V2Interface
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("copy interface failed: %w", err)
+	}
+
+	docs := make(map[string]string)
+	visitStrings(&out, func(path string, s *string, doc bool) {
+		*s = resolve(*s)
+		if doc && *s != "" {
+			docs[*s] = ""
+		}
+	})
+
+	// documents may be files or URLs, each one is read once and they are read together
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, maxContentReads)
+	for _, text := range sortedKeys(docs) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			read := content(text)
+			<-sem
+			mu.Lock()
+			docs[text] = read
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	visitStrings(&out, func(path string, s *string, doc bool) {
+		if doc && *s != "" {
+			*s = docs[*s]
+		}
+	})
+
+	return &out, nil
+}
+
+// visitStrings calls fn with the JSON path of every translatable string of iface.
+// doc is set for descriptions and the welcome message, which may also name a file or URL.
+func visitStrings(iface *V2Interface, fn func(path string, s *string, doc bool)) {
+	fn("label", &iface.Label, false)
+	fn("title", &iface.Title, false)
+	fn("description", &iface.Description, true)
+	fn("welcome", &iface.Welcome, true)
+
+	for i := range iface.Controller {
+		c := &iface.Controller[i]
+		path := fmt.Sprintf("controller[%d]", i)
+		fn(path+".label", &c.Label, false)
+		fn(path+".description", &c.Description, true)
+	}
+	for i := range iface.Resource {
+		r := &iface.Resource[i]
+		path := fmt.Sprintf("resource[%d]", i)
+		fn(path+".label", &r.Label, false)
+		fn(path+".description", &r.Description, true)
+	}
+	for i := range iface.Task {
+		t := &iface.Task[i]
+		path := fmt.Sprintf("task[%d]", i)
+		fn(path+".label", &t.Label, false)
+		fn(path+".description", &t.Description, true)
+	}
+	for i := range iface.Preset {
+		p := &iface.Preset[i]
+		path := fmt.Sprintf("preset[%d]", i)
+		fn(path+".label", &p.Label, false)
+		fn(path+".description", &p.Description, true)
+	}
+	for _, name := range sortedKeys(iface.Option) {
+		opt := iface.Option[name]
+		before := [...]string{opt.Label, opt.Description}
+		path := "option." + name
+		fn(path+".label", &opt.Label, false)
+		fn(path+".description", &opt.Description, true)
+		for i := range opt.Cases {
+			c := &opt.Cases[i]
+			casePath := fmt.Sprintf("%s.cases[%d]", path, i)
+			fn(casePath+".label", &c.Label, false)
+			fn(casePath+".description", &c.Description, true)
+		}
+		for i := range opt.Inputs {
+			in := &opt.Inputs[i]
+			inputPath := fmt.Sprintf("%s.inputs[%d]", path, i)
+			fn(inputPath+".label", &in.Label, false)
+			fn(inputPath+".pattern_msg", &in.PatternMsg, false)
+			fn(inputPath+".description", &in.Description, true)
+		}
+		// cases and inputs share their arrays, only the option fields need to be stored back
+		if before != [...]string{opt.Label, opt.Description} {
+			iface.Option[name] = opt
+		}
+	}
+}
+
+// languageFallback returns the fallback chains configured for the active project
+func (s *service) languageFallback() map[string][]string {
+	s.ifaceMu.RLock()
+	ifacePath := s.ifacePath
+	s.ifaceMu.RUnlock()
+
+	s.registryMu.Lock()
+	defer s.registryMu.Unlock()
+
+	registry, err := s.loadRegistry()
+	if err != nil {
+		return nil
+	}
+	for _, p := range registry.Recent {
+		if p.Path == ifacePath {
+			return p.LanguageFallback
+		}
+	}
+	return nil
+}
+
+// ==================== frontend exposed interfaces ====================
+
+// GetLocalizedInterface gets the interface with every label and description resolved for lang
+func (s *service) GetLocalizedInterface(lang string) (*LocalizedInterface, error) {
+	v2Loaded := s.V2Loaded()
+	if v2Loaded == nil || v2Loaded.Interface == nil {
+		return nil, errors.New("interface not loaded")
+	}
+
+	chain := v2Loaded.FallbackChain(lang, s.languageFallback())
+	resolve := func(str string) string {
+		return v2Loaded.ResolveChain(str, chain)
+	}
+
+	iface, err := localize(v2Loaded.Interface, resolve, s.ReadContent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalizedInterface{
+		Language:  chain[0],
+		Chain:     chain,
+		Interface: iface,
+	}, nil
+}
+
+// GetLanguageFallback gets the fallback chains configured for the active project, keyed by language
+func (s *service) GetLanguageFallback() map[string][]string {
+	fallback := s.languageFallback()
+	if fallback == nil {
+		return map[string][]string{}
+	}
+	return fallback
+}
+
+// SetLanguageFallback sets the fallback chains of the active project, keyed by language.
+// Languages without a chain use their regional variants and DefaultFallbackLanguage.
+func (s *service) SetLanguageFallback(fallback map[string][]string) error {
+	s.ifaceMu.RLock()
+	ifacePath := s.ifacePath
+	s.ifaceMu.RUnlock()
+	if ifacePath == "" {
+		return errors.New("no active project")
+	}
+
+	s.registryMu.Lock()
+	defer s.registryMu.Unlock()
+
+	registry, err := s.loadRegistry()
+	if err != nil {
+		return err
+	}
+	for i := range registry.Recent {
+		if registry.Recent[i].Path == ifacePath {
+			if len(fallback) == 0 {
+				fallback = nil
+			}
+			registry.Recent[i].LanguageFallback = fallback
+			return s.saveRegistry(registry)
+		}
+	}
+	return fmt.Errorf("project not in registry: %s", ifacePath)
+}
@@ -0,0 +1,96 @@
+package pi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallbackChain(t *testing.T) {
+	loaded := &V2Loaded{Interface: &V2Interface{Languages: map[string]string{
+		"zh-CN": "zh_cn.json",
+		"zh-TW": "zh_tw.json",
+		"en-US": "en_us.json",
+		"ja-JP": "ja_jp.json",
+	}}}
+
+	type Case struct {
+		Name       string
+		Lang       string
+		Configured map[string][]string
+		Want       []string
+	}
+
+	cases := []Case{
+		{Name: "regional variants first", Lang: "zh-TW", Want: []string{"zh-TW", "zh-CN", "en-US"}},
+		{Name: "default language only", Lang: "ja-JP", Want: []string{"ja-JP", "en-US"}},
+		{Name: "empty language", Lang: "", Want: []string{"en-US"}},
+		{
+			Name:       "configured chain",
+			Lang:       "ja-JP",
+			Configured: map[string][]string{"ja-JP": {"zh-TW", "zh-CN"}},
+			Want:       []string{"ja-JP", "zh-TW", "zh-CN"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			require.Equal(t, c.Want, loaded.FallbackChain(c.Lang, c.Configured))
+		})
+	}
+}
+
+func TestGetLocalizedInterface(t *testing.T) {
+	s := newTestService(t)
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"interface.json": `{
+			"interface_version": 2,
+			"name": "Test",
+			"label": "$title",
+			"description": "$about",
+			"languages": {"zh-CN": "zh_cn.json", "zh-TW": "zh_tw.json", "en-US": "en_us.json"},
+			"task": [{"name": "A", "entry": "A", "label": "$task", "option": ["Mode"]}],
+			"option": {"Mode": {"label": "$mode", "cases": [{"name": "X", "label": "$case"}, {"name": "Y", "label": "$missing"}]}}
+		}`,
+		"zh_cn.json": `{"title": "测试", "task": "任务", "case": "选项"}`,
+		"zh_tw.json": `{"title": "測試"}`,
+		"en_us.json": `{"title": "Test", "task": "Task", "mode": "Mode", "about": "about.md"}`,
+		"about.md":   "# About",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	require.NoError(t, s.OpenProject(filepath.Join(dir, "interface.json")))
+
+	localized, err := s.GetLocalizedInterface("zh-TW")
+	require.NoError(t, err)
+	require.Equal(t, []string{"zh-TW", "zh-CN", "en-US"}, localized.Chain)
+
+	iface := localized.Interface
+	require.Equal(t, "測試", iface.Label)
+	require.Equal(t, "# About", iface.Description)
+	require.Equal(t, "任务", iface.Task[0].Label)
+	require.Equal(t, "Mode", iface.Option["Mode"].Label)
+	require.Equal(t, "选项", iface.Option["Mode"].Cases[0].Label)
+	require.Equal(t, "missing", iface.Option["Mode"].Cases[1].Label)
+
+	// the loaded interface keeps its keys
+	require.Equal(t, "$title", s.V2Loaded().Interface.Label)
+
+	t.Run("configured fallback", func(t *testing.T) {
+		require.NoError(t, s.SetLanguageFallback(map[string][]string{"zh-TW": {"en-US"}}))
+		require.Equal(t, map[string][]string{"zh-TW": {"en-US"}}, s.GetLanguageFallback())
+
+		localized, err := s.GetLocalizedInterface("zh-TW")
+		require.NoError(t, err)
+		require.Equal(t, "Task", localized.Interface.Task[0].Label)
+
+		// reopening the project keeps its fallback chains
+		require.NoError(t, s.OpenProject(filepath.Join(dir, "interface.json")))
+		require.Equal(t, map[string][]string{"zh-TW": {"en-US"}}, s.GetLanguageFallback())
+	})
+}
@@ -0,0 +1,198 @@
+package pi
+
+import (
+	"fmt"
+	"muu-alpha/backend/jsonc"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// importFile is the content of a file listed in import, only these sections are merged
+type importFile struct {
+	Import     []string            `json:"import,omitempty"`
+	Controller []V2Controller      `json:"controller,omitempty"`
+	Resource   []V2Resource        `json:"resource,omitempty"`
+	Task       []V2Task            `json:"task,omitempty"`
+	Option     map[string]V2Option `json:"option,omitempty"`
+}
+
+// importSource maps a section merged from an imported file back to where it is written
+type importSource struct {
+	prefix string // path in the merged interface, e.g. "task[3]"
+	file   string // imported file, relative to the directory of the interface
+	path   string // path in the imported file, e.g. "task[0]"
+}
+
+// importer merges the imported files into an interface
+type importer struct {
+	iface   *V2Interface
+	baseDir string
+	seen    map[string]bool   // cleaned paths of the files already merged
+	origins map[string]string // section path of a name, e.g. "task.Daily" -> the file defining it
+}
+
+// resolveImports merges the files listed in import of iface, and of the files they import.
+// Import paths are relative to the file listing them, mainPath is the interface file or empty
+// when the data has no file, in which case the paths are relative to the working directory.
+// Names defined in several files are reported as conflicts by ValidateV2, the first definition is kept.
+func resolveImports(iface *V2Interface, mainPath string) error {
+	baseDir := "."
+	if mainPath != "" {
+		baseDir = filepath.Dir(mainPath)
+	}
+	im := &importer{
+		iface:   iface,
+		baseDir: baseDir,
+		seen:    make(map[string]bool),
+		origins: make(map[string]string),
+	}
+	if mainPath != "" {
+		im.seen[filepath.Clean(mainPath)] = true
+	}
+
+	for _, ctrl := range iface.Controller {
+		im.claim("controller", ctrl.Name, "")
+	}
+	for _, res := range iface.Resource {
+		im.claim("resource", res.Name, "")
+	}
+	for _, task := range iface.Task {
+		im.claim("task", task.Name, "")
+	}
+	for name := range iface.Option {
+		im.claim("option", name, "")
+	}
+
+	return im.importAll(baseDir, iface.Import)
+}
+
+// claim records that file defines name in section, false if another file already does.
+func (im *importer) claim(section, name, file string) bool {
+	// missing names and duplicates within a file are left to the validator
+	key := section + "." + name
+	if origin, ok := im.origins[key]; ok && name != "" {
+		return origin == file
+	}
+	im.origins[key] = file
+	return true
+}
+
+// conflict reports a name of file that another file already defines
+func (im *importer) conflict(file, path, section, name string) {
+	origin := im.origins[section+"."+name]
+	if origin == "" {
+		origin = "the main interface"
+	}
+	im.iface.importIssues = append(im.iface.importIssues, Issue{
+		File:     file,
+		Path:     path,
+		Severity: SeverityError,
+		Message:  fmt.Sprintf("%s %s is already defined in %s", section, name, origin),
+	})
+}
+
+// importAll merges the files of paths, relative to dir, in order
+func (im *importer) importAll(dir string, paths []string) error {
+	for _, path := range paths {
+		full := filepath.Clean(filepath.Join(dir, path))
+		if im.seen[full] {
+			continue
+		}
+		im.seen[full] = true
+
+		if err := im.importFile(full); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importFile merges the sections of the file at path and then the files it imports
+func (im *importer) importFile(path string) error {
+	name := path
+	if rel, err := filepath.Rel(im.baseDir, path); err == nil {
+		name = rel
+	}
+	name = filepath.ToSlash(name)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("import %s failed: %w", name, err)
+	}
+	var file importFile
+	if err := jsonc.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("import %s failed: parse JSON failed: %w", name, err)
+	}
+	im.iface.imported = append(im.iface.imported, path)
+
+	iface := im.iface
+	for i, ctrl := range file.Controller {
+		local := fmt.Sprintf("controller[%d]", i)
+		if !im.claim("controller", ctrl.Name, name) {
+			im.conflict(name, local+".name", "controller", ctrl.Name)
+			continue
+		}
+		im.source(fmt.Sprintf("controller[%d]", len(iface.Controller)), name, local)
+		iface.Controller = append(iface.Controller, ctrl)
+	}
+	for i, res := range file.Resource {
+		local := fmt.Sprintf("resource[%d]", i)
+		if !im.claim("resource", res.Name, name) {
+			im.conflict(name, local+".name", "resource", res.Name)
+			continue
+		}
+		im.source(fmt.Sprintf("resource[%d]", len(iface.Resource)), name, local)
+		iface.Resource = append(iface.Resource, res)
+	}
+	for i, task := range file.Task {
+		local := fmt.Sprintf("task[%d]", i)
+		if !im.claim("task", task.Name, name) {
+			im.conflict(name, local+".name", "task", task.Name)
+			continue
+		}
+		im.source(fmt.Sprintf("task[%d]", len(iface.Task)), name, local)
+		iface.Task = append(iface.Task, task)
+	}
+	for _, optName := range sortedKeys(file.Option) {
+		local := "option." + optName
+		if !im.claim("option", optName, name) {
+			im.conflict(name, local, "option", optName)
+			continue
+		}
+		if iface.Option == nil {
+			iface.Option = make(map[string]V2Option)
+		}
+		im.source(local, name, local)
+		iface.Option[optName] = file.Option[optName]
+	}
+
+	return im.importAll(filepath.Dir(path), file.Import)
+}
+
+// source records that the section at prefix comes from path in file
+func (im *importer) source(prefix, file, path string) {
+	im.iface.sources = append(im.iface.sources, importSource{prefix: prefix, file: file, path: path})
+}
+
+// locate maps a path of the merged interface to the imported file defining it,
+// file is empty for paths of the main interface
+func (iface *V2Interface) locate(path string) (file string, local string) {
+	var found *importSource
+	for i := range iface.sources {
+		src := &iface.sources[i]
+		if !strings.HasPrefix(path, src.prefix) {
+			continue
+		}
+		if rest := path[len(src.prefix):]; rest != "" && rest[0] != '.' && rest[0] != '[' {
+			continue
+		}
+		if found == nil || len(src.prefix) > len(found.prefix) {
+			found = src
+		}
+	}
+	if found == nil {
+		return "", path
+	}
+	return found.file, found.path + path[len(found.prefix):]
+}
@@ -0,0 +1,110 @@
+package pi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles writes files relative to dir, creating their directories
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"interface.json": `{
+			"interface_version": 2,
+			"name": "Split",
+			"import": ["tasks/farm.json", "tasks/farm.json"],
+			"controller": [{"name": "Phone", "type": "Adb"}],
+			"task": [{"name": "A", "entry": "A", "option": ["Mode"]}],
+			"option": {"Mode": {"cases": [{"name": "Fast"}]}}
+		}`,
+		// imports are relative to the file listing them, files imported twice are merged once
+		"tasks/farm.json": `{
+			"import": ["../options.json", "../interface.json"],
+			"resource": [{"name": "Official", "path": ["res"]}],
+			"task": [{"name": "Farm", "entry": "Farm", "option": ["Stages"]}]
+		}`,
+		"options.json": `{"option": {"Stages": {"type": "checkbox", "cases": [{"name": "1-7"}]}}}`,
+	})
+
+	iface, err := ParseV2File(filepath.Join(dir, "interface.json"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"A", "Farm"}, []string{iface.Task[0].Name, iface.Task[1].Name})
+	require.Equal(t, "Official", iface.Resource[0].Name)
+	require.Contains(t, iface.Option, "Stages")
+	require.Equal(t, []string{
+		filepath.Join(dir, "tasks", "farm.json"),
+		filepath.Join(dir, "options.json"),
+	}, iface.imported)
+
+	t.Run("missing file", func(t *testing.T) {
+		writeFiles(t, dir, map[string]string{"broken.json": `{
+			"interface_version": 2, "name": "Broken", "import": ["missing.json"]
+		}`})
+		_, err := ParseV2File(filepath.Join(dir, "broken.json"))
+		require.ErrorContains(t, err, "import missing.json failed")
+	})
+}
+
+func TestImportIssues(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"interface.json": `{
+			"interface_version": 2,
+			"name": "Split",
+			"import": ["more.json"],
+			"task": [{"name": "A", "entry": "A"}],
+			"option": {"Mode": {"cases": [{"name": "Fast"}]}}
+		}`,
+		"more.json": `{
+			"task": [
+				{"name": "A", "entry": "A"},
+				{"name": "B", "entry": "B", "option": ["Missing"]}
+			],
+			"option": {
+				"Mode": {"cases": [{"name": "Slow"}]},
+				"Times": {"type": "input", "inputs": [{"name": "count", "kind": "memo"}]}
+			}
+		}`,
+	})
+	ifacePath := filepath.Join(dir, "interface.json")
+
+	_, err := ParseV2File(ifacePath)
+	require.ErrorContains(t, err, "more.json: task[0].name: task A is already defined in the main interface")
+
+	report, err := LintFile(ifacePath)
+	require.NoError(t, err)
+	require.Equal(t, []Issue{
+		{File: "more.json", Path: "task[0].name", Severity: SeverityError, Message: "task A is already defined in the main interface"},
+		{File: "more.json", Path: "option.Mode", Severity: SeverityError, Message: "option Mode is already defined in the main interface"},
+		{File: "more.json", Path: "task[1].option[0]", Severity: SeverityError, Message: "reference to non-existent option: Missing"},
+		{File: "more.json", Path: "option.Times.inputs[0].kind", Severity: SeverityError, Message: "invalid kind: memo"},
+	}, report.Errors())
+	require.Equal(t, "error: more.json: option.Mode: option Mode is already defined in the main interface", report.Errors()[1].String())
+}
+
+func TestWatchedImports(t *testing.T) {
+	s := newTestService(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"interface.json": `{"interface_version": 2, "name": "Split", "import": ["tasks.json"]}`,
+		"tasks.json":     `{"task": [{"name": "A", "entry": "A"}]}`,
+	})
+	ifacePath := filepath.Join(dir, "interface.json")
+	require.NoError(t, s.OpenProject(ifacePath))
+	require.Equal(t, []string{ifacePath, filepath.Join(dir, "tasks.json")}, s.watchedFiles())
+
+	writeFiles(t, dir, map[string]string{"tasks.json": `{"task": [{"name": "A", "entry": "A"}, {"name": "B", "entry": "B"}]}`})
+	require.NoError(t, s.Reload())
+	require.Len(t, s.V2Loaded().Interface.Task, 2)
+}
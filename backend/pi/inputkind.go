@@ -0,0 +1,260 @@
+package pi
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InputKinds are the kinds of input values, each with its own conversion and constraints
+var InputKinds = []string{"text", "int", "float", "bool", "time", "duration", "date", "enum", "list"}
+
+// GetKind returns the kind of the input, inputs without one follow their pipeline_type
+func (i *V2OptionInput) GetKind() string {
+	if i.Kind != "" {
+		return i.Kind
+	}
+	switch i.PipelineType {
+	case "int", "bool":
+		return i.PipelineType
+	default:
+		return "text"
+	}
+}
+
+// ConvertInputValue converts a value to the type the kind of input stores and checks its constraints:
+// a number for int and float, a boolean for bool, a list of strings for list and a string otherwise.
+// Times are stored as "15:04" or "15:04:05", durations as "1m30s" and dates as "2006-01-02".
+func ConvertInputValue(value interface{}, input V2OptionInput) (interface{}, error) {
+	kind := input.GetKind()
+	switch kind {
+	case "int", "float":
+		n, err := toNumber(value, kind == "int")
+		if err != nil {
+			return nil, err
+		}
+		if err := checkRange(n, input); err != nil {
+			return nil, err
+		}
+		if kind == "int" {
+			return int(n), nil
+		}
+		return n, nil
+
+	case "bool":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return v == "true" || v == "True" || v == "1", nil
+		}
+		return nil, fmt.Errorf("%v is not a bool", value)
+
+	case "list":
+		return toList(value)
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		if value == nil {
+			s = ""
+		} else {
+			s = fmt.Sprint(value)
+		}
+	}
+	switch kind {
+	case "time":
+		s = strings.TrimSpace(s)
+		for _, layout := range []string{"15:04", "15:04:05"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t.Format(layout), nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a time of day like 15:04", s)
+	case "duration":
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("%q is not a duration like 1m30s", s)
+		}
+		return d.String(), nil
+	case "date":
+		t, err := time.Parse(time.DateOnly, strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a date like 2006-01-02", s)
+		}
+		return t.Format(time.DateOnly), nil
+	case "enum":
+		if !slices.Contains(input.Values, s) {
+			return nil, fmt.Errorf("%q is not one of %s", s, strings.Join(input.Values, ", "))
+		}
+	}
+	return s, nil
+}
+
+// PipelineValue converts a value of input to the value placed in a pipeline override,
+// durations are passed as milliseconds
+func PipelineValue(value interface{}, input V2OptionInput) (interface{}, error) {
+	converted, err := ConvertInputValue(value, input)
+	if err != nil {
+		return nil, err
+	}
+	if input.GetKind() == "duration" {
+		d, _ := time.ParseDuration(converted.(string))
+		return d.Milliseconds(), nil
+	}
+	return converted, nil
+}
+
+// DefaultInputValue returns the typed default of an input, or the zero value of its kind
+func DefaultInputValue(input V2OptionInput) interface{} {
+	if input.Default != "" {
+		if value, err := ConvertInputValue(input.Default, input); err == nil {
+			return value
+		}
+	}
+	switch input.GetKind() {
+	case "int", "float":
+		n := 0.0
+		if input.Min != nil {
+			n = *input.Min
+		}
+		if input.GetKind() == "int" {
+			return int(n)
+		}
+		return n
+	case "bool":
+		return false
+	case "list":
+		return []string{}
+	case "enum":
+		if len(input.Values) > 0 {
+			return input.Values[0]
+		}
+	}
+	return ""
+}
+
+// toNumber converts a number or the text of a number, integer only accepts whole numbers
+func toNumber(value interface{}, integer bool) (float64, error) {
+	var n float64
+	switch v := value.(type) {
+	case int:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	case float64:
+		n = v
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", v)
+		}
+		n = f
+	default:
+		return 0, fmt.Errorf("%v is not a number", value)
+	}
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("%v is not a number", value)
+	}
+	if integer && n != math.Trunc(n) {
+		return 0, fmt.Errorf("%v is not an integer", value)
+	}
+	return n, nil
+}
+
+// checkRange checks n against min, max and step of input, steps count from min or 0
+func checkRange(n float64, input V2OptionInput) error {
+	if input.Min != nil && n < *input.Min {
+		return fmt.Errorf("%v is less than %v", n, *input.Min)
+	}
+	if input.Max != nil && n > *input.Max {
+		return fmt.Errorf("%v is greater than %v", n, *input.Max)
+	}
+	if input.Step != nil && *input.Step > 0 {
+		base := 0.0
+		if input.Min != nil {
+			base = *input.Min
+		}
+		steps := (n - base) / *input.Step
+		if math.Abs(steps-math.Round(steps)) > 1e-9 {
+			return fmt.Errorf("%v is not a multiple of %v", n, *input.Step)
+		}
+	}
+	return nil
+}
+
+// toList converts a list, or text with one item per line, to a list of strings
+func toList(value interface{}) ([]string, error) {
+	items := []string{}
+	switch v := value.(type) {
+	case []string:
+		items = append(items, v...)
+	case []interface{}:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%v is not a string", item)
+			}
+			items = append(items, s)
+		}
+	case string:
+		for _, line := range strings.Split(v, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				items = append(items, line)
+			}
+		}
+	case nil:
+	default:
+		return nil, fmt.Errorf("%v is not a list", value)
+	}
+	return items, nil
+}
+
+// validateInputKind checks the kind and constraints of an input and that its default is valid
+func (v *validator) validateInputKind(path string, input V2OptionInput) {
+	if input.Kind != "" && !slices.Contains(InputKinds, input.Kind) {
+		v.errorf(path+".kind", "invalid kind: %s", input.Kind)
+		return
+	}
+	kind := input.GetKind()
+	if input.Kind != "" && input.PipelineType != "" {
+		v.warnf(path+".pipeline_type", "pipeline_type is ignored when kind is set")
+	}
+
+	numeric := kind == "int" || kind == "float"
+	if !numeric {
+		for _, field := range []struct {
+			name  string
+			value *float64
+		}{{"min", input.Min}, {"max", input.Max}, {"step", input.Step}} {
+			if field.value != nil {
+				v.warnf(path+"."+field.name, "%s is only used by int and float", field.name)
+			}
+		}
+	}
+	if numeric && input.Min != nil && input.Max != nil && *input.Min > *input.Max {
+		v.errorf(path+".min", "min is greater than max")
+	}
+	if numeric && input.Step != nil && *input.Step <= 0 {
+		v.errorf(path+".step", "step must be positive")
+	}
+
+	if kind == "enum" && len(input.Values) == 0 {
+		v.errorf(path+".values", "missing values")
+	}
+	if kind != "enum" && len(input.Values) > 0 {
+		v.warnf(path+".values", "values is only used by enum")
+	}
+
+	// inputs without a kind were never checked, their defaults are passed as they are
+	if input.Default != "" {
+		if _, err := ConvertInputValue(input.Default, input); err != nil && input.Kind != "" {
+			v.errorf(path+".default", "invalid default: %v", err)
+		} else if err != nil {
+			v.warnf(path+".default", "invalid default: %v", err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package pi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertInputValue(t *testing.T) {
+	min, max, step := 1.0, 10.0, 0.5
+
+	type Case struct {
+		name     string
+		input    V2OptionInput
+		value    interface{}
+		expected interface{}
+		err      bool
+	}
+
+	testCases := []Case{
+		{name: "text", input: V2OptionInput{}, value: 12.0, expected: "12"},
+		{name: "pipeline_type int", input: V2OptionInput{PipelineType: "int"}, value: "12", expected: 12},
+		{name: "int from float", input: V2OptionInput{Kind: "int"}, value: 3.0, expected: 3},
+		{name: "int not whole", input: V2OptionInput{Kind: "int"}, value: "3.5", err: true},
+		{name: "int below min", input: V2OptionInput{Kind: "int", Min: &min}, value: 0, err: true},
+		{name: "int above max", input: V2OptionInput{Kind: "int", Max: &max}, value: 11, err: true},
+		{name: "float step from min", input: V2OptionInput{Kind: "float", Min: &min, Step: &step}, value: "2.5", expected: 2.5},
+		{name: "float off step", input: V2OptionInput{Kind: "float", Min: &min, Step: &step}, value: 2.2, err: true},
+		{name: "bool", input: V2OptionInput{Kind: "bool"}, value: "1", expected: true},
+		{name: "time", input: V2OptionInput{Kind: "time"}, value: " 4:05", expected: "04:05"},
+		{name: "time with seconds", input: V2OptionInput{Kind: "time"}, value: "23:59:30", expected: "23:59:30"},
+		{name: "time out of range", input: V2OptionInput{Kind: "time"}, value: "25:00", err: true},
+		{name: "duration", input: V2OptionInput{Kind: "duration"}, value: "90s", expected: "1m30s"},
+		{name: "negative duration", input: V2OptionInput{Kind: "duration"}, value: "-1s", err: true},
+		{name: "date", input: V2OptionInput{Kind: "date"}, value: "2026-02-28", expected: "2026-02-28"},
+		{name: "invalid date", input: V2OptionInput{Kind: "date"}, value: "2026-02-30", err: true},
+		{name: "enum", input: V2OptionInput{Kind: "enum", Values: []string{"CN", "EN"}}, value: "EN", expected: "EN"},
+		{name: "enum unknown", input: V2OptionInput{Kind: "enum", Values: []string{"CN", "EN"}}, value: "JP", err: true},
+		{name: "list from lines", input: V2OptionInput{Kind: "list"}, value: "a\n\n b \n", expected: []string{"a", "b"}},
+		{name: "list from array", input: V2OptionInput{Kind: "list"}, value: []interface{}{"a", "b"}, expected: []string{"a", "b"}},
+		{name: "list of numbers", input: V2OptionInput{Kind: "list"}, value: []interface{}{1.0}, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := ConvertInputValue(tc.value, tc.input)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, value)
+		})
+	}
+
+	// durations reach the pipeline as milliseconds
+	value, err := PipelineValue("1m30s", V2OptionInput{Kind: "duration"})
+	require.NoError(t, err)
+	require.Equal(t, int64(90000), value)
+
+	// the zero value of a number respects min
+	require.Equal(t, 1, DefaultInputValue(V2OptionInput{Kind: "int", Min: &min}))
+	require.Equal(t, "CN", DefaultInputValue(V2OptionInput{Kind: "enum", Values: []string{"CN", "EN"}}))
+}
+
+func TestValidateInputKinds(t *testing.T) {
+	iface, err := decodeV2([]byte(`{
+		"interface_version": 2,
+		"name": "Kinds",
+		"task": [{"name": "A", "entry": "A", "option": ["Times"]}],
+		"option": {
+			"Times": {
+				"type": "input",
+				"inputs": [
+					{"name": "count", "kind": "int", "min": 5, "max": 1, "default": "x"},
+					{"name": "rate", "kind": "float", "step": 0},
+					{"name": "region", "kind": "enum"},
+					{"name": "at", "kind": "time", "pipeline_type": "int", "max": 3},
+					{"name": "note", "kind": "memo"},
+					{"name": "old", "pipeline_type": "int", "default": "x", "values": ["a"]}
+				]
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	report := ValidateV2(iface)
+	require.Equal(t, []Issue{
+		{Path: "option.Times.inputs[0].min", Severity: SeverityError, Message: "min is greater than max"},
+		{Path: "option.Times.inputs[0].default", Severity: SeverityError, Message: `invalid default: "x" is not a number`},
+		{Path: "option.Times.inputs[1].step", Severity: SeverityError, Message: "step must be positive"},
+		{Path: "option.Times.inputs[2].values", Severity: SeverityError, Message: "missing values"},
+		{Path: "option.Times.inputs[4].kind", Severity: SeverityError, Message: "invalid kind: memo"},
+	}, report.Errors())
+	require.Equal(t, []Issue{
+		{Path: "option.Times.inputs[3].pipeline_type", Severity: SeverityWarning, Message: "pipeline_type is ignored when kind is set"},
+		{Path: "option.Times.inputs[3].max", Severity: SeverityWarning, Message: "max is only used by int and float"},
+		{Path: "option.Times.inputs[5].values", Severity: SeverityWarning, Message: "values is only used by enum"},
+		{Path: "option.Times.inputs[5].default", Severity: SeverityWarning, Message: `invalid default: "x" is not a number`},
+	}, report.Warnings())
+}
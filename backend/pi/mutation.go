@@ -0,0 +1,338 @@
+package pi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"muu-alpha/backend/events"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// ErrRevisionConflict is returned when a change is based on an outdated revision of the config
+var ErrRevisionConflict = errors.New("config was changed by another client")
+
+// cloneConfig returns a deep copy of config, so a failed change leaves the original untouched
+func cloneConfig(config *InterfaceConfig) *InterfaceConfig {
+	clone := *config
+	if config.Adb != nil {
+		adb := *config.Adb
+		clone.Adb = &adb
+	}
+	if config.Win32 != nil {
+		win32 := *config.Win32
+		clone.Win32 = &win32
+	}
+	clone.KnownTasks = append([]string(nil), config.KnownTasks...)
+	clone.Task = make([]ConfigTask, len(config.Task))
+	for i, task := range config.Task {
+		options := make([]ConfigTaskOption, len(task.Option))
+		for j, opt := range task.Option {
+			options[j] = ConfigTaskOption{Name: opt.Name, Value: cloneValue(opt.Value)}
+		}
+		if task.Option == nil {
+			options = nil
+		}
+		task.Option = options
+		clone.Task[i] = task
+	}
+	if config.Extra != nil {
+		clone.Extra = make(map[string]json.RawMessage, len(config.Extra))
+		for k, v := range config.Extra {
+			clone.Extra[k] = v
+		}
+	}
+	return &clone
+}
+
+// mutate applies fn to a copy of the config if revision is the current one.
+// The config is saved and a ConfigChanged event published, the new revision is returned.
+func (s *service) mutate(revision int64, change string, fn func(config *InterfaceConfig, iface *V2Interface) error) (int64, error) {
+	v2Loaded := s.V2Loaded()
+	if v2Loaded == nil || v2Loaded.Interface == nil {
+		return 0, errors.New("interface not loaded")
+	}
+
+	s.configMu.Lock()
+	if revision != s.revision {
+		current := s.revision
+		s.configMu.Unlock()
+		return current, fmt.Errorf("%w: expected revision %d, current %d", ErrRevisionConflict, revision, current)
+	}
+	config := cloneConfig(s.currentConfig())
+	if err := fn(config, v2Loaded.Interface); err != nil {
+		s.configMu.Unlock()
+		return revision, err
+	}
+	s.recordChange(change, config)
+	s.config = config
+	s.revision++
+	changed := events.ConfigChanged{Revision: s.revision, Profile: s.profile, Change: change}
+	s.configMu.Unlock()
+
+	// the change stays in memory and is written with the next save when this one fails,
+	// so clients have to follow it either way
+	err := s.saveConfig()
+	events.Publish(changed)
+	return changed.Revision, err
+}
+
+// currentConfig returns the config, or an empty one before it is loaded. configMu must be held.
+func (s *service) currentConfig() *InterfaceConfig {
+	if s.config == nil {
+		return &InterfaceConfig{Controller: ConfigController{}, Task: []ConfigTask{}}
+	}
+	return s.config
+}
+
+// taskIndex returns the index of the task with id in config
+func taskIndex(config *InterfaceConfig, id string) (int, error) {
+	for i := range config.Task {
+		if config.Task[i].ID == id {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("task does not exist: %s", id)
+}
+
+// checkOptionValue checks that value can be set on the expected option name of a task
+// and returns the value to store: a case name, a list of case names or the input values
+// merged into the current ones
+func (s *service) checkOptionValue(iface *V2Interface, piTask *V2Task, task *ConfigTask, scope OptionScope, name string, value interface{}) (interface{}, error) {
+	current := make(map[string]interface{})
+	for _, opt := range task.Option {
+		current[opt.Name] = opt.Value
+	}
+	expected := getExpectedOptions(piTask.Option, current, iface.Option, scope)
+	scope = scope.withValues(expected)
+
+	for _, opt := range expected {
+		if opt.Name != name {
+			continue
+		}
+		def := iface.Option[name]
+		switch def.GetType() {
+		case "checkbox":
+			list, ok := value.([]interface{})
+			if !ok {
+				if names, isNames := value.([]string); isNames {
+					list = make([]interface{}, len(names))
+					for i, n := range names {
+						list[i] = n
+					}
+				} else {
+					return nil, fmt.Errorf("option %s expects a list of case names", name)
+				}
+			}
+			names := []string{}
+			for _, item := range list {
+				caseName, ok := item.(string)
+				if !ok || findCase(&def, caseName) == nil {
+					return nil, fmt.Errorf("option %s has no case %v", name, item)
+				}
+				names = append(names, caseName)
+			}
+			return names, nil
+
+		case "input":
+			values, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("option %s expects an object of input values", name)
+			}
+			merged := opt.InputValues()
+			visible := visibleInputs(def, scope, iface.Option)
+			for key, v := range values {
+				if findInput(def.Inputs, key) == nil {
+					return nil, fmt.Errorf("option %s has no input %s", name, key)
+				}
+				input := findInput(visible, key)
+				if input == nil {
+					return nil, fmt.Errorf("input %s of option %s is hidden", key, name)
+				}
+				converted, err := ConvertInputValue(v, *input)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value of option %s.%s: %w", name, key, err)
+				}
+				if err := verifyInput(name, input, converted); err != nil {
+					return nil, err
+				}
+				merged[key] = converted
+			}
+			return merged, nil
+
+		default:
+			caseName, ok := value.(string)
+			if !ok || findCase(&def, caseName) == nil {
+				return nil, fmt.Errorf("option %s has no case %v", name, value)
+			}
+			return caseName, nil
+		}
+	}
+	return nil, fmt.Errorf("option %s is not used by task %s", name, task.Name)
+}
+
+// findInput returns the input by name
+func findInput(inputs []V2OptionInput, name string) *V2OptionInput {
+	for i := range inputs {
+		if inputs[i].Name == name {
+			return &inputs[i]
+		}
+	}
+	return nil
+}
+
+// verifyInput checks value against the verify pattern of an input
+func verifyInput(optName string, input *V2OptionInput, value interface{}) error {
+	if input.Verify == "" {
+		return nil
+	}
+	re, err := regexp.Compile(input.Verify)
+	if err != nil {
+		return fmt.Errorf("invalid verify of option %s.%s: %w", optName, input.Name, err)
+	}
+	// every item of a list must match
+	texts := []string{fmt.Sprint(value)}
+	if items, ok := value.([]string); ok {
+		texts = items
+	}
+	for _, text := range texts {
+		if re.MatchString(text) {
+			continue
+		}
+		if input.PatternMsg != "" {
+			return fmt.Errorf("invalid value of option %s.%s: %s", optName, input.Name, input.PatternMsg)
+		}
+		return fmt.Errorf("invalid value of option %s.%s: must match %s", optName, input.Name, input.Verify)
+	}
+	return nil
+}
+
+// ==================== frontend exposed interfaces ====================
+
+// GetRevision gets the revision of the config, every change increments it
+func (s *service) GetRevision() int64 {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.revision
+}
+
+// AddTask adds a task of the interface at index with its default options, an index out of range appends it
+func (s *service) AddTask(revision int64, name string, index int) (int64, error) {
+	return s.mutate(revision, "add-task", func(config *InterfaceConfig, iface *V2Interface) error {
+		piTask := findTask(iface, name)
+		if piTask == nil {
+			return fmt.Errorf("task does not exist: %s", name)
+		}
+		task := ConfigTask{
+			ID:      uuid.New().String(),
+			Name:    name,
+			Checked: true,
+			Option:  s.initTaskOptions(piTask.Option, iface.Option),
+		}
+		if index < 0 || index > len(config.Task) {
+			index = len(config.Task)
+		}
+		config.Task = append(config.Task[:index], append([]ConfigTask{task}, config.Task[index:]...)...)
+		return nil
+	})
+}
+
+// RemoveTask removes a task from the config
+func (s *service) RemoveTask(revision int64, id string) (int64, error) {
+	return s.mutate(revision, "remove-task", func(config *InterfaceConfig, iface *V2Interface) error {
+		i, err := taskIndex(config, id)
+		if err != nil {
+			return err
+		}
+		config.Task = append(config.Task[:i], config.Task[i+1:]...)
+		return nil
+	})
+}
+
+// MoveTask moves a task to index, an index out of range moves it to the end
+func (s *service) MoveTask(revision int64, id string, index int) (int64, error) {
+	return s.mutate(revision, "move-task", func(config *InterfaceConfig, iface *V2Interface) error {
+		i, err := taskIndex(config, id)
+		if err != nil {
+			return err
+		}
+		task := config.Task[i]
+		config.Task = append(config.Task[:i], config.Task[i+1:]...)
+		if index < 0 || index > len(config.Task) {
+			index = len(config.Task)
+		}
+		config.Task = append(config.Task[:index], append([]ConfigTask{task}, config.Task[index:]...)...)
+		return nil
+	})
+}
+
+// SetTaskChecked checks or unchecks a task
+func (s *service) SetTaskChecked(revision int64, id string, checked bool) (int64, error) {
+	return s.mutate(revision, "check-task", func(config *InterfaceConfig, iface *V2Interface) error {
+		i, err := taskIndex(config, id)
+		if err != nil {
+			return err
+		}
+		config.Task[i].Checked = checked
+		return nil
+	})
+}
+
+// SetTaskOption sets an option value of a task: a case name, a list of case names for a checkbox,
+// or an object of input values that is merged into the current ones.
+// Options nested in the previous case are replaced with the ones of the new case.
+func (s *service) SetTaskOption(revision int64, id string, name string, value interface{}) (int64, error) {
+	return s.mutate(revision, "set-option", func(config *InterfaceConfig, iface *V2Interface) error {
+		i, err := taskIndex(config, id)
+		if err != nil {
+			return err
+		}
+		task := &config.Task[i]
+		piTask := findTask(iface, task.Name)
+		if piTask == nil {
+			return fmt.Errorf("task does not exist in the interface: %s", task.Name)
+		}
+		scope := ScopeOf(config, nil)
+		value, err := s.checkOptionValue(iface, piTask, task, scope, name, value)
+		if err != nil {
+			return err
+		}
+
+		set := false
+		for j := range task.Option {
+			if task.Option[j].Name == name {
+				task.Option[j].Value = value
+				set = true
+			}
+		}
+		if !set {
+			task.Option = append(task.Option, ConfigTaskOption{Name: name, Value: value})
+		}
+		s.syncTaskConfigOptions(task, piTask.Option, iface.Option)
+		return nil
+	})
+}
+
+// SetController selects a controller of the interface
+func (s *service) SetController(revision int64, name string) (int64, error) {
+	return s.mutate(revision, "set-controller", func(config *InterfaceConfig, iface *V2Interface) error {
+		ctrl := findController(iface, name)
+		if ctrl == nil {
+			return fmt.Errorf("controller does not exist: %s", name)
+		}
+		config.Controller = ConfigController{Name: ctrl.Name, Type: ctrl.Type}
+		return nil
+	})
+}
+
+// SetResource selects a resource of the interface
+func (s *service) SetResource(revision int64, name string) (int64, error) {
+	return s.mutate(revision, "set-resource", func(config *InterfaceConfig, iface *V2Interface) error {
+		if findResource(iface, name) == nil {
+			return fmt.Errorf("resource does not exist: %s", name)
+		}
+		config.Resource = name
+		return nil
+	})
+}
@@ -0,0 +1,211 @@
+package pi
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"muu-alpha/backend/events"
+
+	"github.com/stretchr/testify/require"
+)
+
+const mutationInterface = `{
+	"interface_version": 2,
+	"name": "Mutation",
+	"controller": [{"name": "Phone", "type": "Adb"}, {"name": "Desktop", "type": "Win32"}],
+	"resource": [{"name": "Official", "path": ["res"]}, {"name": "Global", "path": ["res_global"]}],
+	"task": [
+		{"name": "A", "entry": "A", "option": ["Mode"]},
+		{"name": "B", "entry": "B"}
+	],
+	"option": {
+		"Mode": {"cases": [{"name": "Fast"}, {"name": "Custom", "option": ["Times"]}]},
+		"Times": {"type": "input", "inputs": [{"name": "count", "pipeline_type": "int", "verify": "^[0-9]+$"}]}
+	}
+}`
+
+func TestMutations(t *testing.T) {
+	s, ifacePath := openTestProject(t, mutationInterface)
+
+	changes := []events.ConfigChanged{}
+	unsubscribe := events.Subscribe(func(e events.Event) {
+		if changed, ok := e.(events.ConfigChanged); ok {
+			changes = append(changes, changed)
+		}
+	})
+	defer unsubscribe()
+
+	revision := s.GetRevision()
+	next := func(rev int64, err error) {
+		t.Helper()
+		require.NoError(t, err)
+		require.Equal(t, revision+1, rev)
+		revision = rev
+	}
+
+	next(s.AddTask(revision, "B", 0))
+	config := s.GetConfig()
+	require.Equal(t, []string{"B", "A", "B"}, taskNames(config))
+	require.True(t, config.Task[0].Checked)
+	added := config.Task[0].ID
+	first := config.Task[1].ID
+
+	next(s.MoveTask(revision, added, -1))
+	require.Equal(t, added, s.GetConfig().Task[2].ID)
+
+	next(s.SetTaskChecked(revision, first, true))
+	require.True(t, s.GetConfig().Task[0].Checked)
+
+	t.Run("options", func(t *testing.T) {
+		next(s.SetTaskOption(revision, first, "Mode", "Custom"))
+		require.Equal(t, []ConfigTaskOption{
+			{Name: "Mode", Value: "Custom"},
+			{Name: "Times", Value: map[string]interface{}{"count": 0}},
+		}, s.GetConfig().Task[0].Option)
+
+		// input values are converted to the pipeline type
+		next(s.SetTaskOption(revision, first, "Times", map[string]interface{}{"count": "12"}))
+		require.Equal(t, map[string]interface{}{"count": 12}, s.GetConfig().Task[0].Option[1].Value)
+
+		type Case struct {
+			name  string
+			value interface{}
+		}
+		for _, tc := range []Case{
+			{name: "Mode", value: "Slow"},
+			{name: "Mode", value: []interface{}{"Fast"}},
+			{name: "Times", value: "12"},
+			{name: "Times", value: map[string]interface{}{"count": "twelve"}},
+			{name: "Times", value: map[string]interface{}{"count": -1}},
+			{name: "Times", value: map[string]interface{}{"missing": 1}},
+			{name: "Missing", value: "x"},
+		} {
+			_, err := s.SetTaskOption(revision, first, tc.name, tc.value)
+			require.Error(t, err, "%s %v", tc.name, tc.value)
+		}
+
+		// nested options of the previous case are dropped
+		next(s.SetTaskOption(revision, first, "Mode", "Fast"))
+		require.Equal(t, []ConfigTaskOption{{Name: "Mode", Value: "Fast"}}, s.GetConfig().Task[0].Option)
+	})
+
+	t.Run("controller and resource", func(t *testing.T) {
+		next(s.SetController(revision, "Desktop"))
+		require.Equal(t, ConfigController{Name: "Desktop", Type: "Win32"}, s.GetConfig().Controller)
+		next(s.SetResource(revision, "Global"))
+		require.Equal(t, "Global", s.GetConfig().Resource)
+
+		_, err := s.SetController(revision, "Missing")
+		require.Error(t, err)
+		_, err = s.SetResource(revision, "Missing")
+		require.Error(t, err)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		current, err := s.RemoveTask(revision-1, added)
+		require.ErrorIs(t, err, ErrRevisionConflict)
+		require.Equal(t, revision, current)
+		require.Len(t, s.GetConfig().Task, 3)
+		_, err = s.SaveConfig(revision-1, &InterfaceConfig{Task: []ConfigTask{}})
+		require.ErrorIs(t, err, ErrRevisionConflict)
+		require.Len(t, s.GetConfig().Task, 3)
+
+		next(s.RemoveTask(revision, added))
+		require.Equal(t, []string{"A", "B"}, taskNames(s.GetConfig()))
+	})
+
+	// every change was saved and announced
+	saved, _, err := readConfig(s.projectConfigPath(ifacePath))
+	require.NoError(t, err)
+	require.Equal(t, taskNames(s.GetConfig()), taskNames(saved))
+	require.Equal(t, s.GetConfig().Task[0], saved.Task[0])
+	require.NotEmpty(t, changes)
+	require.Equal(t, revision, changes[len(changes)-1].Revision)
+	require.Equal(t, "remove-task", changes[len(changes)-1].Change)
+}
+
+func taskNames(config *InterfaceConfig) []string {
+	names := []string{}
+	for _, task := range config.Task {
+		names = append(names, task.Name)
+	}
+	return names
+}
+
+func TestMutationSaveFailure(t *testing.T) {
+	s, ifacePath := openTestProject(t, mutationInterface)
+
+	changes := []events.ConfigChanged{}
+	unsubscribe := events.Subscribe(func(e events.Event) {
+		if changed, ok := e.(events.ConfigChanged); ok {
+			changes = append(changes, changed)
+		}
+	})
+	defer unsubscribe()
+
+	// the directory of the config is a file, so saving fails
+	configPath := s.configPath
+	s.configPath = filepath.Join(ifacePath, "interface_config.json")
+	defer func() { s.configPath = configPath }()
+
+	revision := s.GetRevision()
+	tasks := taskNames(s.GetConfig())
+	rev, err := s.AddTask(revision, "B", -1)
+	require.Error(t, err)
+	require.Equal(t, revision+1, rev)
+	require.Equal(t, rev, s.GetRevision())
+	require.Equal(t, append(tasks, "B"), taskNames(s.GetConfig()))
+	require.Len(t, changes, 1)
+	require.Equal(t, rev, changes[0].Revision)
+}
+
+func TestConcurrentMutations(t *testing.T) {
+	s, ifacePath := openTestProject(t, mutationInterface)
+	id := s.GetConfig().Task[0].ID
+
+	// the GUI and a remote client change the config at the same time,
+	// each retries with the current revision when the other one was faster
+	retry := func(change func(revision int64) (int64, error)) error {
+		for {
+			_, err := change(s.GetRevision())
+			if !errors.Is(err, ErrRevisionConflict) {
+				return err
+			}
+		}
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- retry(func(revision int64) (int64, error) {
+				return s.SetTaskChecked(revision, id, i%2 == 0)
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- retry(func(revision int64) (int64, error) {
+				return s.AddTask(revision, "B", -1)
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// the file holds the newest revision
+	config := s.GetConfig()
+	require.Len(t, config.Task, 22)
+	saved, _, err := readConfig(s.projectConfigPath(ifacePath))
+	require.NoError(t, err)
+	require.Len(t, saved.Task, len(config.Task))
+	for i, task := range config.Task {
+		require.Equal(t, task.ID, saved.Task[i].ID)
+		require.Equal(t, task.Checked, saved.Task[i].Checked)
+	}
+}
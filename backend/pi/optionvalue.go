@@ -0,0 +1,273 @@
+package pi
+
+import (
+	"encoding/json"
+	"muu-alpha/backend/events"
+	"muu-alpha/backend/migrate"
+	"regexp"
+	"strings"
+)
+
+// CaseName returns the case selected by a select or switch option, empty when the value is not a string
+func (o ConfigTaskOption) CaseName() string {
+	name, _ := o.Value.(string)
+	return name
+}
+
+// CaseNames returns the cases selected by a checkbox option.
+// Values written before typed values, a JSON array in a string, are accepted too.
+func (o ConfigTaskOption) CaseNames() []string {
+	names := []string{}
+	switch v := o.Value.(type) {
+	case []string:
+		names = append(names, v...)
+	case []interface{}:
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	case string:
+		_ = json.Unmarshal([]byte(v), &names)
+	}
+	return names
+}
+
+// InputValues returns the values of an input option by input name
+func (o ConfigTaskOption) InputValues() map[string]interface{} {
+	values, _ := o.Value.(map[string]interface{})
+	return values
+}
+
+// normalizeInputValues converts the stored values of input option optName to the types of inputs.
+// Inputs missing from value or with a value they do not accept get their default,
+// values of other inputs are dropped.
+func normalizeInputValues(optName string, inputs []V2OptionInput, value interface{}) map[string]interface{} {
+	current := ConfigTaskOption{Value: value}.InputValues()
+	values := make(map[string]interface{}, len(inputs))
+	for _, input := range inputs {
+		v, ok := current[input.Name]
+		if !ok {
+			values[input.Name] = DefaultInputValue(input)
+			continue
+		}
+		converted, err := checkInputValue(optName, v, input)
+		if err != nil {
+			converted = DefaultInputValue(input)
+		}
+		values[input.Name] = converted
+	}
+	return values
+}
+
+// checkInputValue converts a stored value of an input of option optName and checks its constraints
+// and verify, a verify that does not compile is left to lint
+func checkInputValue(optName string, value interface{}, input V2OptionInput) (interface{}, error) {
+	converted, err := ConvertInputValue(value, input)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := regexp.Compile(input.Verify); err != nil {
+		return converted, nil
+	}
+	if err := verifyInput(optName, &input, converted); err != nil {
+		return nil, err
+	}
+	return converted, nil
+}
+
+// invalidInputValues reports the stored input values of task that their inputs do not accept,
+// the sync replaces them with the defaults
+func invalidInputValues(task *ConfigTask, optionDefs map[string]V2Option) []events.InputReset {
+	resets := []events.InputReset{}
+	for _, opt := range task.Option {
+		def, ok := optionDefs[opt.Name]
+		if !ok || def.GetType() != "input" {
+			continue
+		}
+		values := opt.InputValues()
+		for _, input := range def.Inputs {
+			value, ok := values[input.Name]
+			if !ok {
+				continue
+			}
+			if _, err := checkInputValue(opt.Name, value, input); err != nil {
+				resets = append(resets, events.InputReset{
+					Task:   events.TaskRef{ID: task.ID, Name: task.Name},
+					Option: opt.Name,
+					Input:  input.Name,
+					Value:  value,
+					Reason: err.Error(),
+				})
+			}
+		}
+	}
+	return resets
+}
+
+// resetUnknownCases drops the cases of task the options no longer have. A select or switch
+// with an unknown case loses its value, so the sync fills in the default case.
+func resetUnknownCases(task *ConfigTask, optionDefs map[string]V2Option) []events.CaseReset {
+	resets := []events.CaseReset{}
+	reset := func(optName string, caseName string) {
+		resets = append(resets, events.CaseReset{
+			Task:   events.TaskRef{ID: task.ID, Name: task.Name},
+			Option: optName,
+			Case:   caseName,
+		})
+	}
+
+	options := make([]ConfigTaskOption, 0, len(task.Option))
+	for _, opt := range task.Option {
+		def, ok := optionDefs[opt.Name]
+		switch {
+		case !ok || def.GetType() == "input":
+		case def.GetType() == "checkbox":
+			known := []string{}
+			for _, caseName := range opt.CaseNames() {
+				if findCase(&def, caseName) == nil {
+					reset(opt.Name, caseName)
+					continue
+				}
+				known = append(known, caseName)
+			}
+			if len(known) != len(opt.CaseNames()) {
+				opt.Value = known
+			}
+		case len(def.Cases) > 0 && opt.CaseName() != "" && findCase(&def, opt.CaseName()) == nil:
+			reset(opt.Name, opt.CaseName())
+			continue
+		}
+		options = append(options, opt)
+	}
+	task.Option = options
+	return resets
+}
+
+// cloneValue returns a deep copy of a decoded JSON value
+func cloneValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		clone := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			clone[k] = cloneValue(item)
+		}
+		return clone
+	case []interface{}:
+		clone := make([]interface{}, len(v))
+		for i, item := range v {
+			clone[i] = cloneValue(item)
+		}
+		return clone
+	case []string:
+		return append([]string{}, v...)
+	default:
+		return v
+	}
+}
+
+// groupInputValues groups the flattened "option.input" values of config version 1 into one
+// object per input option. A name is only split where its start names an input option of optionDefs
+// with an input named by the rest, so options with dots in their name are kept.
+// Returns true if any value was grouped.
+func groupInputValues(options []ConfigTaskOption, optionDefs map[string]V2Option) ([]ConfigTaskOption, bool) {
+	grouped := make([]ConfigTaskOption, 0, len(options))
+	index := make(map[string]int) // option name -> index in grouped
+	changed := false
+	for _, opt := range options {
+		optName, inputName, ok := splitInputName(opt.Name, optionDefs)
+		if !ok {
+			index[opt.Name] = len(grouped)
+			grouped = append(grouped, opt)
+			continue
+		}
+
+		changed = true
+		i, seen := index[optName]
+		if !seen {
+			i = len(grouped)
+			index[optName] = i
+			grouped = append(grouped, ConfigTaskOption{Name: optName, Value: map[string]interface{}{}})
+		}
+		values := grouped[i].InputValues()
+		if values == nil {
+			values = map[string]interface{}{}
+		}
+		if _, ok := values[inputName]; !ok {
+			values[inputName] = opt.Value
+		}
+		grouped[i].Value = values
+	}
+	return grouped, changed
+}
+
+// splitInputName splits a flattened "option.input" name of an input option of optionDefs
+func splitInputName(name string, optionDefs map[string]V2Option) (optName string, inputName string, found bool) {
+	if _, ok := optionDefs[name]; ok {
+		return "", "", false
+	}
+	for i := strings.LastIndex(name, "."); i > 0; i = strings.LastIndex(name[:i], ".") {
+		def, ok := optionDefs[name[:i]]
+		if !ok || def.GetType() != "input" {
+			continue
+		}
+		for _, input := range def.Inputs {
+			if input.Name == name[i+1:] {
+				return name[:i], input.Name, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// migrateOptionValues turns the checkbox values of config version 1, stored as JSON strings, into arrays.
+// The flattened "option.input" values are kept, they are grouped by groupInputValues when the config
+// is synced, as only the interface tells an input apart from an option with a dot in its name.
+func migrateOptionValues(doc migrate.Doc) error {
+	raw, ok := doc["task"]
+	if !ok {
+		return nil
+	}
+	var tasks []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &tasks); err != nil {
+		return err
+	}
+
+	type flatOption struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	for _, task := range tasks {
+		rawOptions, ok := task["option"]
+		if !ok {
+			continue
+		}
+		var flat []flatOption
+		if err := json.Unmarshal(rawOptions, &flat); err != nil {
+			return err
+		}
+
+		options := []ConfigTaskOption{}
+		for _, opt := range flat {
+			var names []string
+			if strings.HasPrefix(opt.Value, "[") && json.Unmarshal([]byte(opt.Value), &names) == nil {
+				options = append(options, ConfigTaskOption{Name: opt.Name, Value: names})
+				continue
+			}
+			options = append(options, ConfigTaskOption{Name: opt.Name, Value: opt.Value})
+		}
+
+		data, err := json.Marshal(options)
+		if err != nil {
+			return err
+		}
+		task["option"] = data
+	}
+
+	data, err := json.Marshal(tasks)
+	if err != nil {
+		return err
+	}
+	doc["task"] = data
+	return nil
+}
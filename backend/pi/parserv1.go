@@ -0,0 +1,198 @@
+package pi
+
+import (
+	"fmt"
+	"muu-alpha/backend/jsonc"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// v1ProjectDir is the placeholder of the project directory in v1 paths
+const v1ProjectDir = "{PROJECT_DIR}"
+
+// ParseV1 parses the data into a V1Interface
+func ParseV1(data []byte) (*V1Interface, error) {
+	var iface V1Interface
+	if err := jsonc.Unmarshal(data, &iface); err != nil {
+		return nil, fmt.Errorf("parse JSON failed: %w", err)
+	}
+
+	if err := validateV1(&iface); err != nil {
+		return nil, err
+	}
+
+	return &iface, nil
+}
+
+// ParseV1File parses the file into a V1Interface
+func ParseV1File(path string) (*V1Interface, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file failed: %w", err)
+	}
+	return ParseV1(data)
+}
+
+// validateV1 validates the V1Interface
+// Only checks the v1 specific shapes, the converted interface is validated by validateV2
+func validateV1(iface *V1Interface) error {
+	if iface.InterfaceVersion != 0 && iface.InterfaceVersion != 1 {
+		return fmt.Errorf("version mismatch: expected 1, got %d", iface.InterfaceVersion)
+	}
+
+	for i, ctrl := range iface.Controller {
+		if ctrl.Type != "Adb" && ctrl.Type != "Win32" {
+			return fmt.Errorf("controller[%d]: invalid type: %s", i, ctrl.Type)
+		}
+	}
+
+	for name, opt := range iface.Option {
+		if len(opt.Cases) == 0 {
+			return fmt.Errorf("option[%s]: missing cases", name)
+		}
+	}
+
+	return nil
+}
+
+// ConvertV1ToV2 converts a V1Interface into the equivalent V2Interface
+//
+// v1 has no i18n, so names double as labels. "{PROJECT_DIR}" in paths is made
+// relative to the interface directory. Adb input and screencap methods have no
+// v2 counterpart and are dropped, win32 methods are kept as numeric values.
+func ConvertV1ToV2(v1 *V1Interface) *V2Interface {
+	iface := &V2Interface{
+		InterfaceVersion: 2,
+		Name:             v1.Name,
+		Version:          v1.Version,
+		Welcome:          v1.Message,
+		Github:           v1.URL,
+		MirrorchyanRID:   v1.MirrorchyanRID,
+		Controller:       make([]V2Controller, 0, len(v1.Controller)),
+		Resource:         make([]V2Resource, 0, len(v1.Resource)),
+		Task:             make([]V2Task, 0, len(v1.Task)),
+		Option:           make(map[string]V2Option, len(v1.Option)),
+	}
+
+	for _, ctrl := range v1.Controller {
+		c := V2Controller{
+			Name:  ctrl.Name,
+			Label: ctrl.Name,
+			Type:  ctrl.Type,
+		}
+		switch ctrl.Type {
+		case "Adb":
+			c.Adb = &V2AdbConfig{}
+		case "Win32":
+			c.Win32 = &V2Win32Config{}
+			if ctrl.Win32 != nil {
+				c.Win32.ClassRegex = ctrl.Win32.ClassRegex
+				c.Win32.WindowRegex = ctrl.Win32.WindowRegex
+				if ctrl.Win32.Input != nil {
+					// v1 uses one input method for both mouse and keyboard
+					c.Win32.Mouse = strconv.Itoa(*ctrl.Win32.Input)
+					c.Win32.Keyboard = strconv.Itoa(*ctrl.Win32.Input)
+				}
+				if ctrl.Win32.Screencap != nil {
+					c.Win32.Screencap = strconv.Itoa(*ctrl.Win32.Screencap)
+				}
+			}
+		}
+		iface.Controller = append(iface.Controller, c)
+	}
+
+	for _, res := range v1.Resource {
+		paths := make([]string, 0, len(res.Path))
+		for _, p := range res.Path {
+			paths = append(paths, convertV1Path(p))
+		}
+		iface.Resource = append(iface.Resource, V2Resource{
+			Name:  res.Name,
+			Label: res.Name,
+			Path:  paths,
+		})
+	}
+
+	if v1.Agent != nil {
+		args := make([]string, 0, len(v1.Agent.ChildArgs))
+		for _, arg := range v1.Agent.ChildArgs {
+			args = append(args, convertV1Path(arg))
+		}
+		iface.Agent = &V2Agent{
+			ChildExec:  convertV1Path(v1.Agent.ChildExec),
+			ChildArgs:  args,
+			Identifier: v1.Agent.Identifier,
+		}
+	}
+
+	for _, task := range v1.Task {
+		iface.Task = append(iface.Task, V2Task{
+			Name:             task.Name,
+			Label:            task.Name,
+			Entry:            task.Entry,
+			Description:      string(task.Doc),
+			PipelineOverride: task.PipelineOverride,
+			Option:           task.Option,
+		})
+	}
+
+	for name, opt := range v1.Option {
+		cases := make([]V2OptionCase, 0, len(opt.Cases))
+		for _, c := range opt.Cases {
+			cases = append(cases, V2OptionCase{
+				Name:             c.Name,
+				Label:            c.Name,
+				PipelineOverride: c.PipelineOverride,
+			})
+		}
+		iface.Option[name] = V2Option{
+			Type:        "select",
+			Label:       name,
+			Description: string(opt.Doc),
+			Cases:       cases,
+			DefaultCase: opt.DefaultCase,
+		}
+	}
+
+	return iface
+}
+
+// convertV1Path replaces the "{PROJECT_DIR}" placeholder with a path relative to the project directory
+func convertV1Path(p string) string {
+	if !strings.Contains(p, v1ProjectDir) {
+		return p
+	}
+	p = strings.ReplaceAll(p, v1ProjectDir, ".")
+	return path.Clean(filepath.ToSlash(p))
+}
+
+// LoadV1FromFile loads a v1 interface from a file and converts it to a V2Loaded
+func LoadV1FromFile(path string) (*V2Loaded, error) {
+	v1, err := ParseV1File(path)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := filepath.Dir(path)
+
+	iface := ConvertV1ToV2(v1)
+	if iface.Name == "" {
+		// name is optional in v1 but required in v2, fall back to the project directory name
+		if abs, err := filepath.Abs(basePath); err == nil {
+			iface.Name = filepath.Base(abs)
+		}
+	}
+
+	if err := validateV2(iface); err != nil {
+		return nil, fmt.Errorf("converted v1 interface is invalid: %w", err)
+	}
+
+	return &V2Loaded{
+		Interface: iface,
+		Resolvers: make(map[string]*V2I18nResolver),
+		BasePath:  basePath,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package pi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const validV1 = `{
+	"name": "LegacyProject",
+	"version": "v1.2.3",
+	"message": "hello",
+	"controller": [
+		{"name": "Android", "type": "Adb", "adb": {"input": 1, "screencap": 2}},
+		{"name": "Desktop", "type": "Win32", "win32": {"class_regex": "Game", "window_regex": ".*", "input": 4, "screencap": 2}}
+	],
+	"resource": [
+		{"name": "Official", "path": ["{PROJECT_DIR}/resource/base", "{PROJECT_DIR}/resource/official"]}
+	],
+	"agent": {"child_exec": "python", "child_args": ["{PROJECT_DIR}/agent/main.py"]},
+	"task": [
+		{"name": "Start", "entry": "StartUp", "doc": ["line1", "line2"], "option": ["Stage"]}
+	],
+	"option": {
+		"Stage": {
+			"cases": [
+				{"name": "1-7", "pipeline_override": {"Stage": {"text": "1-7"}}},
+				{"name": "2-4"}
+			],
+			"default_case": "2-4"
+		}
+	}
+}`
+
+func TestParseV1(t *testing.T) {
+	t.Run("valid v1 interface", func(t *testing.T) {
+		iface, err := ParseV1([]byte(validV1))
+		require.NoError(t, err)
+		require.Equal(t, "LegacyProject", iface.Name)
+		require.Equal(t, 2, len(iface.Controller))
+		require.Equal(t, V1Doc("line1\nline2"), iface.Task[0].Doc)
+	})
+
+	t.Run("wrong version", func(t *testing.T) {
+		_, err := ParseV1([]byte(`{"interface_version": 2}`))
+		require.Error(t, err)
+	})
+
+	t.Run("invalid controller type", func(t *testing.T) {
+		_, err := ParseV1([]byte(`{"controller": [{"name": "X", "type": "Invalid"}]}`))
+		require.Error(t, err)
+	})
+
+	t.Run("option without cases", func(t *testing.T) {
+		_, err := ParseV1([]byte(`{"option": {"X": {}}}`))
+		require.Error(t, err)
+	})
+}
+
+func TestConvertV1ToV2(t *testing.T) {
+	v1, err := ParseV1([]byte(validV1))
+	require.NoError(t, err)
+
+	iface := ConvertV1ToV2(v1)
+	require.NoError(t, validateV2(iface))
+
+	require.Equal(t, 2, iface.InterfaceVersion)
+	require.Equal(t, "hello", iface.Welcome)
+
+	win32 := iface.Controller[1].Win32
+	require.NotNil(t, win32)
+	require.Equal(t, "Game", win32.ClassRegex)
+	require.Equal(t, "4", win32.Mouse)
+	require.Equal(t, "4", win32.Keyboard)
+	require.Equal(t, "2", win32.Screencap)
+
+	require.Equal(t, []string{"resource/base", "resource/official"}, iface.Resource[0].Path)
+	require.Equal(t, []string{"agent/main.py"}, iface.Agent.ChildArgs)
+
+	require.Equal(t, "Start", iface.Task[0].Label)
+	require.Equal(t, "line1\nline2", iface.Task[0].Description)
+
+	opt := iface.Option["Stage"]
+	require.Equal(t, "select", opt.GetType())
+	require.Equal(t, "2-4", opt.DefaultCase)
+	require.JSONEq(t, `{"Stage": {"text": "1-7"}}`, string(opt.Cases[0].PipelineOverride))
+}
+
+func TestLoadV1FromFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := filepath.Join(tmpDir, "MyProject")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+
+	// name is optional in v1
+	filePath := filepath.Join(projectDir, "interface.json")
+	content := `{"task": [{"name": "Start", "entry": "StartUp"}]}`
+	require.NoError(t, os.WriteFile(filePath, []byte(content), 0644))
+
+	loaded, err := LoadV1FromFile(filePath)
+	require.NoError(t, err)
+	require.Equal(t, "MyProject", loaded.Interface.Name)
+	require.Equal(t, projectDir, loaded.BasePath)
+	require.Equal(t, 1, len(loaded.Interface.Task))
+}
@@ -187,6 +187,30 @@ func TestParseV2(t *testing.T) {
 		_, err := ParseV2([]byte(data))
 		require.Error(t, err)
 	})
+
+	t.Run("comments and trailing commas", func(t *testing.T) {
+		data := `{
+			// MaaFramework accepts JSONC
+			"interface_version": 2,
+			"name": "Test", /* inline */
+			"task": [
+				{"name": "A", "entry": "A", "pipeline_override": {"A": {"next": ["B",]}}},
+			],
+		}`
+		iface, err := ParseV2([]byte(data))
+		require.NoError(t, err)
+		require.Equal(t, "A", iface.Task[0].Name)
+
+		version, err := DetectVersion([]byte(data))
+		require.NoError(t, err)
+		require.Equal(t, Version2, version)
+	})
+
+	t.Run("error position", func(t *testing.T) {
+		data := "{\n\t// comment\n\t\"interface_version\": 2,\n\t\"name\" \"Test\"\n}"
+		_, err := ParseV2([]byte(data))
+		require.ErrorContains(t, err, "line 4, column 9: invalid character")
+	})
 }
 
 func TestV2OptionGetType(t *testing.T) {
@@ -200,6 +224,7 @@ func TestV2OptionGetType(t *testing.T) {
 		{V2Option{Type: "select"}, "select"},
 		{V2Option{Type: "input"}, "input"},
 		{V2Option{Type: "switch"}, "switch"},
+		{V2Option{Type: "checkbox"}, "checkbox"},
 	}
 
 	for _, tc := range testCases {
@@ -2,10 +2,14 @@ package pi
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
+	"muu-alpha/backend/events"
+	"muu-alpha/backend/jsonc"
+	"muu-alpha/backend/migrate"
 	"os"
 	"path/filepath"
 	"sync"
@@ -23,60 +27,73 @@ func PI() *service {
 			if err != nil {
 				exePath = "."
 			}
-			exeDir := filepath.Dir(exePath)
-
-			srvInst = &service{
-				version:    VersionUnknown,
-				v2Loaded:   nil,
-				config:     nil,
-				configPath: filepath.Join(exeDir, "config", "interface_config.json"),
-			}
+			srvInst = newService(filepath.Dir(exePath))
 		})
 	}
 	return srvInst
 }
 
+// newService creates a service keeping its files in the config directory next to exeDir
+func newService(exeDir string) *service {
+	configDir := filepath.Join(exeDir, "config")
+	return &service{
+		version:      VersionUnknown,
+		v2Loaded:     nil,
+		config:       nil,
+		exeDir:       exeDir,
+		configDir:    configDir,
+		configPath:   filepath.Join(configDir, "interface_config.json"),
+		registryPath: filepath.Join(configDir, "projects.json"),
+	}
+}
+
 func Startup(ctx context.Context) {
 	s := PI()
 
 	s.ctx = ctx
 
-	exePath, err := os.Executable()
-	if err != nil {
-		log.Printf("get executable path failed: %v", err)
-		return
+	ifacePath := startupProject
+	if ifacePath == "" {
+		ifacePath = s.activeProjectPath()
 	}
-	exeDir := filepath.Dir(exePath)
-
-	ifacePath := filepath.Join(exeDir, "interface.json")
-	data, err := os.ReadFile(ifacePath)
-	if err != nil {
-		log.Printf("read interface file failed: %v", err)
-		return
+	if err := s.OpenProject(ifacePath); err != nil {
+		log.Printf("open project failed: %v", err)
 	}
 
-	version, err := DetectVersion(data)
+	go s.watch(ctx)
+}
+
+// Load loads the interface at ifacePath and the config at configPath into the service.
+// A missing or broken config is replaced with the default config.
+func Load(ifacePath string, configPath string) error {
+	return PI().load(ifacePath, configPath)
+}
+
+// load is Load for s
+func (s *service) load(ifacePath string, configPath string) error {
+	version, v2Loaded, err := loadInterface(ifacePath)
 	if err != nil {
-		log.Printf("detect version failed: %v", err)
-		return
+		return err
 	}
-	s.version = version
-
-	if version == Version2 {
-		v2Loaded, err := LoadV2FromFile(ifacePath)
-		if err != nil {
-			log.Printf("load v2 interface failed: %v", err)
-			return
-		}
-		log.Printf("v2 interface loaded: %+v", v2Loaded)
-		s.v2Loaded = v2Loaded
-	} else {
-		log.Printf("unknown version: %d", version)
-		return
+	s.setLoaded(version, v2Loaded, ifacePath)
+
+	events.Publish(events.InterfaceLoaded{
+		Name:     v2Loaded.Interface.Name,
+		Version:  v2Loaded.Interface.Version,
+		BasePath: v2Loaded.BasePath,
+	})
+
+	s.configMu.Lock()
+	s.configPath = configPath
+	s.profile = DefaultProfile
+	s.configMu.Unlock()
+
+	// 加载配置, only a missing or undecodable config is replaced
+	err = s.loadConfig()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) && !migrate.IsDecodeError(err) {
+		return fmt.Errorf("load config failed: %w", err)
 	}
-
-	// 加载配置
-	if err := s.loadConfig(); err != nil {
+	if err != nil && (errors.Is(err, fs.ErrNotExist) || !s.recoverConfig()) {
 		log.Printf("load config failed, initializing default config: %v", err)
 		s.initDefaultConfig()
 		// 保存默认配置到文件
@@ -84,14 +101,52 @@ func Startup(ctx context.Context) {
 			log.Printf("save default config failed: %v", err)
 		}
 	} else {
-		// 同步配置中的 option（补全遗漏的，移除多余的）
-		if s.syncConfigOptions() {
+		// 同步配置中的 task 和 option（补全遗漏的，移除多余的）
+		report, changed := s.syncConfigOptions()
+		if changed {
 			// 如果有变更，保存配置
 			if err := s.saveConfig(); err != nil {
 				log.Printf("save synced config failed: %v", err)
 			}
 		}
+		notifySync(report)
+	}
+
+	return nil
+}
+
+// loadInterface detects the version of the interface at path and loads it as v2
+func loadInterface(path string) (Version, *V2Loaded, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return VersionUnknown, nil, fmt.Errorf("read interface file failed: %w", err)
+	}
+
+	version, err := DetectVersion(data)
+	if err != nil {
+		return VersionUnknown, nil, fmt.Errorf("detect version failed: %w", err)
+	}
+
+	var v2Loaded *V2Loaded
+	switch version {
+	case Version2:
+		v2Loaded, err = LoadV2FromFile(path)
+		if err != nil {
+			return version, nil, fmt.Errorf("load v2 interface failed: %w", err)
+		}
+		log.Printf("v2 interface loaded: %+v", v2Loaded)
+	case Version1:
+		// v1 interfaces are converted, the rest of the app only deals with v2
+		v2Loaded, err = LoadV1FromFile(path)
+		if err != nil {
+			return version, nil, fmt.Errorf("load v1 interface failed: %w", err)
+		}
+		log.Printf("v1 interface loaded as v2: %+v", v2Loaded)
+	default:
+		return version, nil, fmt.Errorf("unknown version: %d", version)
 	}
+
+	return version, v2Loaded, nil
 }
 
 // Version represents the version of the PI protocol
@@ -123,7 +178,7 @@ type versionInfo struct {
 // DetectVersion detects the version of the interface
 func DetectVersion(data []byte) (Version, error) {
 	var info versionInfo
-	if err := json.Unmarshal(data, &info); err != nil {
+	if err := jsonc.Unmarshal(data, &info); err != nil {
 		return VersionUnknown, fmt.Errorf("parse version information failed: %w", err)
 	}
 
@@ -0,0 +1,98 @@
+package pi
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// V1Interface represents the interface of the v1 version
+type V1Interface struct {
+	InterfaceVersion int                 `json:"interface_version,omitempty"`
+	Name             string              `json:"name,omitempty"`
+	Version          string              `json:"version,omitempty"`
+	Message          string              `json:"message,omitempty"`
+	URL              string              `json:"url,omitempty"`
+	MirrorchyanRID   string              `json:"mirrorchyan_rid,omitempty"`
+	Controller       []V1Controller      `json:"controller,omitempty"`
+	Resource         []V1Resource        `json:"resource,omitempty"`
+	Agent            *V1Agent            `json:"agent,omitempty"`
+	Task             []V1Task            `json:"task,omitempty"`
+	Option           map[string]V1Option `json:"option,omitempty"`
+}
+
+// V1Controller represents the controller of the v1 version
+type V1Controller struct {
+	Name  string         `json:"name"`
+	Type  string         `json:"type"`
+	Adb   *V1AdbConfig   `json:"adb,omitempty"`
+	Win32 *V1Win32Config `json:"win32,omitempty"`
+}
+
+// V1AdbConfig represents the adb config of the v1 version
+type V1AdbConfig struct {
+	Input     *int                   `json:"input,omitempty"`
+	Screencap *int                   `json:"screencap,omitempty"`
+	Config    map[string]interface{} `json:"config,omitempty"`
+}
+
+// V1Win32Config represents the win32 config of the v1 version
+type V1Win32Config struct {
+	ClassRegex  string `json:"class_regex,omitempty"`
+	WindowRegex string `json:"window_regex,omitempty"`
+	Input       *int   `json:"input,omitempty"`
+	Screencap   *int   `json:"screencap,omitempty"`
+}
+
+// V1Resource represents the resource of the v1 version
+type V1Resource struct {
+	Name string   `json:"name"`
+	Path []string `json:"path"`
+}
+
+// V1Agent represents the agent of the v1 version
+type V1Agent struct {
+	ChildExec  string   `json:"child_exec"`
+	ChildArgs  []string `json:"child_args,omitempty"`
+	Identifier string   `json:"identifier,omitempty"`
+}
+
+// V1Task represents the task of the v1 version
+type V1Task struct {
+	Name             string          `json:"name"`
+	Entry            string          `json:"entry"`
+	Doc              V1Doc           `json:"doc,omitempty"`
+	PipelineOverride json.RawMessage `json:"pipeline_override,omitempty"`
+	Option           []string        `json:"option,omitempty"`
+}
+
+// V1Option represents the option of the v1 version, v1 options are always selects
+type V1Option struct {
+	Cases       []V1OptionCase `json:"cases"`
+	DefaultCase string         `json:"default_case,omitempty"`
+	Doc         V1Doc          `json:"doc,omitempty"`
+}
+
+// V1OptionCase represents the option case of the v1 version
+type V1OptionCase struct {
+	Name             string          `json:"name"`
+	PipelineOverride json.RawMessage `json:"pipeline_override,omitempty"`
+}
+
+// V1Doc is a documentation field that is either a string or a list of lines
+type V1Doc string
+
+// UnmarshalJSON accepts both a string and a list of strings
+func (d *V1Doc) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*d = V1Doc(s)
+		return nil
+	}
+
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*d = V1Doc(strings.Join(lines, "\n"))
+	return nil
+}
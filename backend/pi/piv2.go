@@ -1,6 +1,8 @@
 package pi
 
-import "encoding/json"
+import (
+	"encoding/json"
+)
 
 // V2Interface represents the interface of the v2 version
 type V2Interface struct {
@@ -23,6 +25,12 @@ type V2Interface struct {
 	Agent                    *V2Agent            `json:"agent,omitempty"`
 	Task                     []V2Task            `json:"task,omitempty"`
 	Option                   map[string]V2Option `json:"option,omitempty"`
+	Preset                   []V2Preset          `json:"preset,omitempty"`
+	Import                   []string            `json:"import,omitempty"` // files whose controller, resource, task and option sections are merged
+
+	sources      []importSource // sections merged from imported files
+	imported     []string       // paths of the imported files
+	importIssues []Issue        // conflicts between imported files
 }
 
 // V2Controller represents the controller of the v2 version
@@ -31,7 +39,7 @@ type V2Controller struct {
 	Label            string         `json:"label,omitempty"`
 	Description      string         `json:"description,omitempty"`
 	Icon             string         `json:"icon,omitempty"`
-	Type             string         `json:"type"`
+	Type             string         `json:"type" jsonschema:"enum=Adb|Win32"`
 	DisplayShortSide *int           `json:"display_short_side,omitempty"`
 	DisplayLongSide  *int           `json:"display_long_side,omitempty"`
 	DisplayRaw       bool           `json:"display_raw,omitempty"`
@@ -79,11 +87,12 @@ type V2Task struct {
 	Resource         []string        `json:"resource,omitempty"`
 	PipelineOverride json.RawMessage `json:"pipeline_override,omitempty"`
 	Option           []string        `json:"option,omitempty"`
+	Aliases          []string        `json:"aliases,omitempty"` // previous names, config tasks using them are renamed
 }
 
 // V2Option represents the option of the v2 version
 type V2Option struct {
-	Type             string          `json:"type,omitempty"`
+	Type             string          `json:"type,omitempty" jsonschema:"enum=select|switch|input|checkbox"`
 	Label            string          `json:"label,omitempty"`
 	Description      string          `json:"description,omitempty"`
 	Icon             string          `json:"icon,omitempty"`
@@ -91,6 +100,8 @@ type V2Option struct {
 	Inputs           []V2OptionInput `json:"inputs,omitempty"`
 	PipelineOverride json.RawMessage `json:"pipeline_override,omitempty"`
 	DefaultCase      string          `json:"default_case,omitempty"`
+	DefaultCases     []string        `json:"default_cases,omitempty"` // cases a checkbox starts with
+	When             *V2Condition    `json:"when,omitempty"`          // the option is only shown when it holds
 }
 
 // GetType returns the type of the option
@@ -101,6 +112,32 @@ func (o *V2Option) GetType() string {
 	return o.Type
 }
 
+// SelectedCases returns the cases of a checkbox that names selects, in declaration order.
+// Unknown case names select nothing.
+func (o *V2Option) SelectedCases(names []string) []*V2OptionCase {
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[name] = true
+	}
+
+	cases := []*V2OptionCase{}
+	for i := range o.Cases {
+		if selected[o.Cases[i].Name] {
+			cases = append(cases, &o.Cases[i])
+		}
+	}
+	return cases
+}
+
+// DefaultSelection returns the cases a checkbox starts with
+func (o *V2Option) DefaultSelection() []string {
+	names := []string{}
+	for _, c := range o.SelectedCases(o.DefaultCases) {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
 // V2OptionCase represents the option case of the v2 version
 type V2OptionCase struct {
 	Name             string          `json:"name"`
@@ -113,11 +150,17 @@ type V2OptionCase struct {
 
 // V2OptionInput represents the option input of the v2 version
 type V2OptionInput struct {
-	Name         string `json:"name"`
-	Label        string `json:"label,omitempty"`
-	Description  string `json:"description,omitempty"`
-	Default      string `json:"default,omitempty"`
-	PipelineType string `json:"pipeline_type,omitempty"`
-	Verify       string `json:"verify,omitempty"`
-	PatternMsg   string `json:"pattern_msg,omitempty"`
+	Name         string       `json:"name"`
+	Label        string       `json:"label,omitempty"`
+	Description  string       `json:"description,omitempty"`
+	Default      string       `json:"default,omitempty"`
+	PipelineType string       `json:"pipeline_type,omitempty" jsonschema:"enum=string|int|bool"`
+	Kind         string       `json:"kind,omitempty" jsonschema:"enum=text|int|float|bool|time|duration|date|enum|list"` // overrides pipeline_type
+	Min          *float64     `json:"min,omitempty"`                                                                     // int and float
+	Max          *float64     `json:"max,omitempty"`                                                                     // int and float
+	Step         *float64     `json:"step,omitempty"`                                                                    // int and float, counted from min
+	Values       []string     `json:"values,omitempty"`                                                                  // choices of enum
+	Verify       string       `json:"verify,omitempty"`
+	PatternMsg   string       `json:"pattern_msg,omitempty"`
+	When         *V2Condition `json:"when,omitempty"` // the input is only shown when it holds
 }
@@ -0,0 +1,177 @@
+package pi
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// V2Preset is a ready-made task selection shipped with the interface
+type V2Preset struct {
+	Name        string         `json:"name"`
+	Label       string         `json:"label,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Task        []V2PresetTask `json:"task"`
+}
+
+// V2PresetTask is a task of a preset with the option values it starts from,
+// options that are not listed get their defaults
+type V2PresetTask struct {
+	Name   string                 `json:"name"`
+	Option map[string]interface{} `json:"option,omitempty"` // option name -> value as stored in the config
+}
+
+func findPreset(iface *V2Interface, name string) *V2Preset {
+	for i := range iface.Preset {
+		if iface.Preset[i].Name == name {
+			return &iface.Preset[i]
+		}
+	}
+	return nil
+}
+
+// presetTasks builds the config tasks of a preset, its option values go through the sync
+// so missing options get their defaults
+func (s *service) presetTasks(iface *V2Interface, preset *V2Preset) ([]ConfigTask, error) {
+	tasks := []ConfigTask{}
+	for _, presetTask := range preset.Task {
+		piTask := findTask(iface, presetTask.Name)
+		if piTask == nil {
+			return nil, fmt.Errorf("task of preset %s does not exist: %s", preset.Name, presetTask.Name)
+		}
+		values := make(map[string]interface{}, len(presetTask.Option))
+		for name, value := range presetTask.Option {
+			values[name] = cloneValue(value)
+		}
+		tasks = append(tasks, ConfigTask{
+			ID:      uuid.New().String(),
+			Name:    piTask.Name,
+			Checked: true,
+			Option:  getExpectedOptions(piTask.Option, values, iface.Option, storedScope()),
+		})
+	}
+	return tasks, nil
+}
+
+// taskOptionNames returns every option a task can show, through the cases of its options
+func taskOptionNames(iface *V2Interface, task *V2Task) map[string]bool {
+	names := make(map[string]bool)
+	var visit func(optionNames []string)
+	visit = func(optionNames []string) {
+		for _, name := range optionNames {
+			def, ok := iface.Option[name]
+			if !ok || names[name] {
+				continue
+			}
+			names[name] = true
+			for _, c := range def.Cases {
+				visit(c.Option)
+			}
+		}
+	}
+	visit(task.Option)
+	return names
+}
+
+// validatePresets checks that presets refer to existing tasks, options and cases
+func (v *validator) validatePresets() {
+	presetNames := make(map[string]bool)
+	for i, preset := range v.iface.Preset {
+		path := fmt.Sprintf("preset[%d]", i)
+
+		if preset.Name == "" {
+			v.errorf(path+".name", "missing name")
+		} else if presetNames[preset.Name] {
+			v.errorf(path+".name", "duplicate name: %s", preset.Name)
+		}
+		presetNames[preset.Name] = true
+
+		if len(preset.Task) == 0 {
+			v.warnf(path+".task", "preset has no task")
+		}
+
+		for j, presetTask := range preset.Task {
+			taskPath := fmt.Sprintf("%s.task[%d]", path, j)
+			task := findTask(v.iface, presetTask.Name)
+			if task == nil {
+				v.errorf(taskPath+".name", "reference to non-existent task: %s", presetTask.Name)
+				continue
+			}
+
+			used := taskOptionNames(v.iface, task)
+			for _, optName := range sortedKeys(presetTask.Option) {
+				optPath := taskPath + ".option." + optName
+				if !used[optName] {
+					v.errorf(optPath, "option not used by task %s: %s", task.Name, optName)
+					continue
+				}
+				v.validatePresetValue(optPath, optName, presetTask.Option[optName])
+			}
+		}
+	}
+}
+
+// validatePresetValue checks a preset value against the type of its option
+func (v *validator) validatePresetValue(path string, optName string, value interface{}) {
+	def := v.iface.Option[optName]
+	switch def.GetType() {
+	case "checkbox":
+		items, ok := value.([]interface{})
+		if !ok {
+			v.errorf(path, "checkbox value must be a list of case names")
+			return
+		}
+		for i, item := range items {
+			caseName, _ := item.(string)
+			if findCase(&def, caseName) == nil {
+				v.errorf(fmt.Sprintf("%s[%d]", path, i), "case does not exist: %v", item)
+			}
+		}
+
+	case "input":
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			v.errorf(path, "input value must be an object of input values")
+			return
+		}
+		for _, inputName := range sortedKeys(values) {
+			input := findInput(def.Inputs, inputName)
+			if input == nil {
+				v.errorf(path+"."+inputName, "input does not exist: %s", inputName)
+				continue
+			}
+			if _, err := ConvertInputValue(values[inputName], *input); err != nil {
+				v.errorf(path+"."+inputName, "invalid value: %v", err)
+			}
+		}
+
+	default:
+		caseName, ok := value.(string)
+		if !ok {
+			v.errorf(path, "%s value must be a case name", def.GetType())
+			return
+		}
+		if findCase(&def, caseName) == nil {
+			v.errorf(path, "case does not exist: %s", caseName)
+		}
+	}
+}
+
+// ==================== frontend exposed interfaces ====================
+
+// ApplyPreset replaces the tasks of the config with the tasks of a preset of the interface
+// and returns the new revision, the change can be undone
+func (s *service) ApplyPreset(revision int64, name string) (int64, error) {
+	return s.mutate(revision, "apply-preset", func(config *InterfaceConfig, iface *V2Interface) error {
+		preset := findPreset(iface, name)
+		if preset == nil {
+			return fmt.Errorf("preset does not exist: %s", name)
+		}
+		tasks, err := s.presetTasks(iface, preset)
+		if err != nil {
+			return err
+		}
+		config.Task = tasks
+		return nil
+	})
+}
@@ -0,0 +1,105 @@
+package pi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const presetInterface = `{
+	"interface_version": 2,
+	"name": "Presets",
+	"controller": [{"name": "Phone", "type": "Adb"}],
+	"task": [
+		{"name": "A", "entry": "A", "default_check": true, "option": ["Mode"]},
+		{"name": "B", "entry": "B", "option": ["Stages"]}
+	],
+	"option": {
+		"Mode": {"cases": [{"name": "Fast"}, {"name": "Custom", "option": ["Times"]}]},
+		"Times": {"type": "input", "inputs": [{"name": "count", "kind": "int", "default": "1"}]},
+		"Stages": {"type": "checkbox", "cases": [{"name": "1-7"}, {"name": "3-1"}], "default_cases": ["1-7"]}
+	},
+	"preset": [
+		{
+			"name": "Daily",
+			"task": [
+				{"name": "B", "option": {"Stages": ["3-1"]}},
+				{"name": "A", "option": {"Mode": "Custom", "Times": {"count": 5}}},
+				{"name": "A"}
+			]
+		}
+	]
+}`
+
+func TestApplyPreset(t *testing.T) {
+	s, _ := openTestProject(t, presetInterface)
+
+	_, err := s.ApplyPreset(s.GetRevision(), "Weekly")
+	require.Error(t, err)
+
+	_, err = s.ApplyPreset(s.GetRevision()+1, "Daily")
+	require.ErrorIs(t, err, ErrRevisionConflict)
+
+	revision, err := s.ApplyPreset(s.GetRevision(), "Daily")
+	require.NoError(t, err)
+	require.Equal(t, s.GetRevision(), revision)
+
+	tasks := s.GetConfig().Task
+	require.Equal(t, []string{"B", "A", "A"}, taskNames(s.GetConfig()))
+	for _, task := range tasks {
+		require.True(t, task.Checked)
+	}
+	require.Equal(t, []ConfigTaskOption{{Name: "Stages", Value: []string{"3-1"}}}, tasks[0].Option)
+	require.Equal(t, []ConfigTaskOption{
+		{Name: "Mode", Value: "Custom"},
+		{Name: "Times", Value: map[string]interface{}{"count": 5}},
+	}, tasks[1].Option)
+	// options the preset does not list get their defaults
+	require.Equal(t, []ConfigTaskOption{{Name: "Mode", Value: "Fast"}}, tasks[2].Option)
+
+	_, err = s.Undo()
+	require.NoError(t, err)
+	require.Equal(t, []string{"A", "B"}, taskNames(s.GetConfig()))
+}
+
+func TestValidatePresets(t *testing.T) {
+	iface, err := decodeV2([]byte(`{
+		"interface_version": 2,
+		"name": "Presets",
+		"task": [
+			{"name": "A", "entry": "A", "option": ["Mode"]},
+			{"name": "B", "entry": "B", "option": ["Stages"]}
+		],
+		"option": {
+			"Mode": {"cases": [{"name": "Fast"}, {"name": "Custom", "option": ["Times"]}]},
+			"Times": {"type": "input", "inputs": [{"name": "count", "kind": "int"}]},
+			"Stages": {"type": "checkbox", "cases": [{"name": "1-7"}]}
+		},
+		"preset": [
+			{
+				"name": "Daily",
+				"task": [
+					{"name": "A", "option": {"Mode": ["Fast"], "Times": {"count": "x", "server": "eu"}, "Stages": ["1-7"]}},
+					{"name": "B", "option": {"Stages": ["1-7", "9-9"]}},
+					{"name": "C"}
+				]
+			},
+			{"name": "Daily", "task": []}
+		]
+	}`))
+	require.NoError(t, err)
+
+	report := ValidateV2(iface)
+	require.Equal(t, []Issue{
+		{Path: "preset[0].task[0].option.Mode", Severity: SeverityError, Message: "select value must be a case name"},
+		{Path: "preset[0].task[0].option.Stages", Severity: SeverityError, Message: "option not used by task A: Stages"},
+		{Path: "preset[0].task[0].option.Times.count", Severity: SeverityError, Message: `invalid value: "x" is not a number`},
+		{Path: "preset[0].task[0].option.Times.server", Severity: SeverityError, Message: "input does not exist: server"},
+		{Path: "preset[0].task[1].option.Stages[1]", Severity: SeverityError, Message: "case does not exist: 9-9"},
+		{Path: "preset[0].task[2].name", Severity: SeverityError, Message: "reference to non-existent task: C"},
+		{Path: "preset[1].name", Severity: SeverityError, Message: "duplicate name: Daily"},
+	}, report.Errors())
+	require.Equal(t, []Issue{
+		{Path: "preset[1].task", Severity: SeverityWarning, Message: "preset has no task"},
+	}, report.Warnings())
+}
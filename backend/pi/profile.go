@@ -0,0 +1,335 @@
+package pi
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"muu-alpha/backend/events"
+	"muu-alpha/backend/migrate"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile is the profile kept in the project's interface_config.json,
+// it always exists and cannot be renamed or deleted
+const DefaultProfile = "default"
+
+// ErrInvalidProfileName is returned for profile names that cannot be used as a file name
+var ErrInvalidProfileName = errors.New("invalid profile name")
+
+// ProfileConfigPath returns the config path of a profile of the project whose interface is at ifacePath.
+// Other profiles are stored in a profiles directory next to the default config.
+func ProfileConfigPath(ifacePath string, profile string) (string, error) {
+	return PI().profileConfigPath(ifacePath, profile)
+}
+
+// profileConfigPath is ProfileConfigPath for s
+func (s *service) profileConfigPath(ifacePath string, profile string) (string, error) {
+	if profile == "" || profile == DefaultProfile {
+		return s.projectConfigPath(ifacePath), nil
+	}
+	if err := validateProfileName(profile); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.profilesDir(ifacePath), profile+".json"), nil
+}
+
+// profilesDir returns the directory holding the profiles other than the default one
+func (s *service) profilesDir(ifacePath string) string {
+	return filepath.Join(filepath.Dir(s.projectConfigPath(ifacePath)), "profiles")
+}
+
+// validateProfileName checks that name can be used as a file name
+func validateProfileName(name string) error {
+	if strings.TrimSpace(name) != name || name == "" {
+		return fmt.Errorf("%w: must not be empty or start or end with spaces", ErrInvalidProfileName)
+	}
+	if len(name) > 64 {
+		return fmt.Errorf("%w: too long", ErrInvalidProfileName)
+	}
+	if strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\:*?"<>|`) {
+		return fmt.Errorf("%w: contains invalid characters: %s", ErrInvalidProfileName, name)
+	}
+	return nil
+}
+
+// activeIfacePath returns the interface path of the loaded project
+func (s *service) activeIfacePath() (string, error) {
+	s.ifaceMu.RLock()
+	defer s.ifaceMu.RUnlock()
+	if s.ifacePath == "" {
+		return "", errors.New("no active project")
+	}
+	return s.ifacePath, nil
+}
+
+// profilePath returns the config path of an existing profile of the active project
+func (s *service) profilePath(name string) (string, error) {
+	if name != DefaultProfile {
+		if err := validateProfileName(name); err != nil {
+			return "", err
+		}
+	}
+	ifacePath, err := s.activeIfacePath()
+	if err != nil {
+		return "", err
+	}
+	path, err := s.profileConfigPath(ifacePath, name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("profile does not exist: %s", name)
+	}
+	return path, nil
+}
+
+// newProfilePath returns the config path of a profile of the active project that does not exist yet
+func (s *service) newProfilePath(name string) (string, error) {
+	if err := validateProfileName(name); err != nil {
+		return "", err
+	}
+	ifacePath, err := s.activeIfacePath()
+	if err != nil {
+		return "", err
+	}
+	path, err := s.profileConfigPath(ifacePath, name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("profile already exists: %s", name)
+	}
+	return path, nil
+}
+
+// rememberedProfile returns the profile that was active when the project at ifacePath was last used
+func (s *service) rememberedProfile(ifacePath string) string {
+	s.registryMu.Lock()
+	defer s.registryMu.Unlock()
+
+	registry, err := s.loadRegistry()
+	if err != nil {
+		return DefaultProfile
+	}
+	for _, p := range registry.Recent {
+		if p.Path == ifacePath && p.Profile != "" {
+			path, err := s.profileConfigPath(ifacePath, p.Profile)
+			if err != nil {
+				break
+			}
+			if _, err := os.Stat(path); err == nil {
+				return p.Profile
+			}
+		}
+	}
+	return DefaultProfile
+}
+
+// rememberProfile stores the active profile of the project at ifacePath in the registry
+func (s *service) rememberProfile(ifacePath string, name string) {
+	s.registryMu.Lock()
+	defer s.registryMu.Unlock()
+
+	registry, err := s.loadRegistry()
+	if err != nil {
+		log.Printf("load project registry failed: %v", err)
+		return
+	}
+	for i := range registry.Recent {
+		if registry.Recent[i].Path == ifacePath {
+			registry.Recent[i].Profile = name
+			if err := s.saveRegistry(registry); err != nil {
+				log.Printf("save project registry failed: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// LoadProfile loads a profile of the active project synced with the interface, without making it active.
+// The active profile is returned as a copy of the config currently edited. The file is not changed,
+// even if it is migrated.
+func (s *service) LoadProfile(name string) (*InterfaceConfig, error) {
+	if name == "" || name == s.GetActiveProfile() {
+		s.configMu.RLock()
+		defer s.configMu.RUnlock()
+		return cloneConfig(s.currentConfig()), nil
+	}
+
+	config, _, _, err := s.loadProfile(name)
+	return config, err
+}
+
+// loadProfile reads a profile other than the active one and syncs it with the interface.
+// It returns the path of the profile and the version its file has.
+func (s *service) loadProfile(name string) (*InterfaceConfig, string, int, error) {
+	path, err := s.profilePath(name)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	config, from, err := readConfig(path)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	s.syncConfig(config)
+	return config, path, from, nil
+}
+
+// ==================== frontend exposed interfaces ====================
+
+// GetProfiles gets the profile names of the active project, the default profile first
+func (s *service) GetProfiles() ([]string, error) {
+	ifacePath, err := s.activeIfacePath()
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := []string{}
+	entries, err := os.ReadDir(s.profilesDir(ifacePath))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read profiles failed: %w", err)
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if ok && !entry.IsDir() && name != DefaultProfile && validateProfileName(name) == nil {
+			profiles = append(profiles, name)
+		}
+	}
+	sort.Strings(profiles)
+
+	return append([]string{DefaultProfile}, profiles...), nil
+}
+
+// GetActiveProfile gets the name of the profile being edited
+func (s *service) GetActiveProfile() string {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	if s.profile == "" {
+		return DefaultProfile
+	}
+	return s.profile
+}
+
+// CreateProfile creates a profile with the default config
+func (s *service) CreateProfile(name string) error {
+	path, err := s.newProfilePath(name)
+	if err != nil {
+		return err
+	}
+	return writeConfig(path, s.defaultConfig())
+}
+
+// CloneProfile creates a profile with a copy of the config of another one
+func (s *service) CloneProfile(source string, name string) error {
+	config, err := s.LoadProfile(source)
+	if err != nil {
+		return err
+	}
+	path, err := s.newProfilePath(name)
+	if err != nil {
+		return err
+	}
+	return writeConfig(path, config)
+}
+
+// RenameProfile renames a profile, the default profile cannot be renamed
+func (s *service) RenameProfile(name string, newName string) error {
+	if name == DefaultProfile {
+		return errors.New("the default profile cannot be renamed")
+	}
+	path, err := s.profilePath(name)
+	if err != nil {
+		return err
+	}
+	newPath, err := s.newProfilePath(newName)
+	if err != nil {
+		return err
+	}
+
+	s.configMu.Lock()
+	if err := os.Rename(path, newPath); err != nil {
+		s.configMu.Unlock()
+		return fmt.Errorf("rename profile failed: %w", err)
+	}
+	moveUndoHistory(path, newPath)
+	active := s.profile == name
+	if active {
+		s.profile = newName
+		s.configPath = newPath
+	}
+	s.configMu.Unlock()
+
+	if active {
+		if ifacePath, err := s.activeIfacePath(); err == nil {
+			s.rememberProfile(ifacePath, newName)
+		}
+		events.Publish(events.ProfileChanged{Name: newName})
+	}
+	return nil
+}
+
+// DeleteProfile deletes a profile, the active and the default profile cannot be deleted
+func (s *service) DeleteProfile(name string) error {
+	if name == DefaultProfile {
+		return errors.New("the default profile cannot be deleted")
+	}
+	if name == s.GetActiveProfile() {
+		return errors.New("the active profile cannot be deleted")
+	}
+	path, err := s.profilePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("delete profile failed: %w", err)
+	}
+	if err := os.Remove(undoPath(path)); err != nil && !os.IsNotExist(err) {
+		log.Printf("delete undo history failed: %v", err)
+	}
+	return nil
+}
+
+// SwitchProfile makes another profile active, the current one is saved first
+func (s *service) SwitchProfile(name string) error {
+	if name == s.GetActiveProfile() {
+		return nil
+	}
+	ifacePath, err := s.activeIfacePath()
+	if err != nil {
+		return err
+	}
+	config, path, from, err := s.loadProfile(name)
+	if err != nil {
+		return err
+	}
+	// keep the original before the migrated config is stored below
+	if from < ConfigVersion {
+		if _, err := migrate.Backup(path, from); err != nil {
+			return fmt.Errorf("backup config before migration failed: %w", err)
+		}
+	}
+
+	if err := s.saveConfig(); err != nil {
+		log.Printf("save config before switching profile failed: %v", err)
+	}
+
+	s.configMu.Lock()
+	s.config = config
+	s.configPath = path
+	s.profile = name
+	s.revision++
+	s.resetUndo()
+	s.configMu.Unlock()
+
+	// store the options synced by LoadProfile
+	if err := s.saveConfig(); err != nil {
+		return err
+	}
+
+	s.rememberProfile(ifacePath, name)
+	events.Publish(events.ProfileChanged{Name: name})
+	return nil
+}
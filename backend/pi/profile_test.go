@@ -0,0 +1,152 @@
+package pi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateProfileName(t *testing.T) {
+	for _, name := range []string{"daily", "event farming", "周常"} {
+		require.NoError(t, validateProfileName(name), name)
+	}
+	for _, name := range []string{"", " daily", ".hidden", "a/b", `a\b`, "a:b"} {
+		require.Error(t, validateProfileName(name), name)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	s := newTestService(t)
+	ifacePath := writeProject(t, t.TempDir(), "Profiles")
+	require.NoError(t, s.OpenProject(ifacePath))
+
+	profiles, err := s.GetProfiles()
+	require.NoError(t, err)
+	require.Equal(t, []string{DefaultProfile}, profiles)
+	require.Equal(t, DefaultProfile, s.GetActiveProfile())
+
+	// check the task in the default profile
+	_, err = s.SetTaskChecked(s.GetRevision(), s.GetConfig().Task[0].ID, true)
+	require.NoError(t, err)
+
+	require.NoError(t, s.CreateProfile("weekly"))
+	require.NoError(t, s.CloneProfile(DefaultProfile, "daily"))
+	require.Error(t, s.CreateProfile("daily"))
+	require.Error(t, s.CloneProfile("missing", "other"))
+
+	profiles, err = s.GetProfiles()
+	require.NoError(t, err)
+	require.Equal(t, []string{DefaultProfile, "daily", "weekly"}, profiles)
+
+	daily, err := s.LoadProfile("daily")
+	require.NoError(t, err)
+	require.True(t, daily.Task[0].Checked)
+	require.Equal(t, DefaultProfile, s.GetActiveProfile())
+
+	// the active profile is a copy, changing it does not change the config
+	active, err := s.LoadProfile(DefaultProfile)
+	require.NoError(t, err)
+	active.Task[0].Checked = false
+	require.True(t, s.GetConfig().Task[0].Checked)
+
+	t.Run("switch", func(t *testing.T) {
+		require.NoError(t, s.SwitchProfile("weekly"))
+		require.Equal(t, "weekly", s.GetActiveProfile())
+		require.False(t, s.GetConfig().Task[0].Checked)
+
+		// the active profile is remembered with the project
+		require.NoError(t, s.OpenProject(ifacePath))
+		require.Equal(t, "weekly", s.GetActiveProfile())
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		require.Error(t, s.RenameProfile(DefaultProfile, "other"))
+		require.Error(t, s.RenameProfile("weekly", "daily"))
+
+		require.NoError(t, s.RenameProfile("weekly", "cleanup"))
+		require.Equal(t, "cleanup", s.GetActiveProfile())
+		path, err := s.profileConfigPath(ifacePath, "cleanup")
+		require.NoError(t, err)
+		_, err = os.Stat(path)
+		require.NoError(t, err)
+
+		_, err = s.SetTaskChecked(s.GetRevision(), s.GetConfig().Task[0].ID, true)
+		require.NoError(t, err)
+		cleanup, _, err := readConfig(path)
+		require.NoError(t, err)
+		require.True(t, cleanup.Task[0].Checked)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.Error(t, s.DeleteProfile(DefaultProfile))
+		require.Error(t, s.DeleteProfile("cleanup"))
+
+		require.NoError(t, s.DeleteProfile("daily"))
+		profiles, err := s.GetProfiles()
+		require.NoError(t, err)
+		require.Equal(t, []string{DefaultProfile, "cleanup"}, profiles)
+	})
+
+	t.Run("profiles are stored next to the project config", func(t *testing.T) {
+		path, err := s.profileConfigPath(ifacePath, "cleanup")
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(filepath.Dir(s.projectConfigPath(ifacePath)), "profiles", "cleanup.json"), path)
+	})
+}
+
+func TestProfileTraversal(t *testing.T) {
+	s := newTestService(t)
+	ifacePath := writeProject(t, t.TempDir(), "Traversal")
+	require.NoError(t, s.OpenProject(ifacePath))
+	require.NoError(t, s.CreateProfile("daily"))
+
+	// resolves to the default config next to the profiles directory
+	const name = "../interface_config"
+	_, err := s.profileConfigPath(ifacePath, name)
+	require.ErrorIs(t, err, ErrInvalidProfileName)
+
+	_, err = s.LoadProfile(name)
+	require.ErrorIs(t, err, ErrInvalidProfileName)
+	require.ErrorIs(t, s.SwitchProfile(name), ErrInvalidProfileName)
+	require.ErrorIs(t, s.DeleteProfile(name), ErrInvalidProfileName)
+	require.ErrorIs(t, s.RenameProfile(name, "other"), ErrInvalidProfileName)
+	require.ErrorIs(t, s.RenameProfile("daily", name), ErrInvalidProfileName)
+	require.ErrorIs(t, s.CloneProfile(name, "other"), ErrInvalidProfileName)
+
+	require.Equal(t, DefaultProfile, s.GetActiveProfile())
+	_, err = os.Stat(s.projectConfigPath(ifacePath))
+	require.NoError(t, err)
+	profiles, err := s.GetProfiles()
+	require.NoError(t, err)
+	require.Equal(t, []string{DefaultProfile, "daily"}, profiles)
+}
+
+func TestLoadProfileKeepsFile(t *testing.T) {
+	s := newTestService(t)
+	ifacePath := writeProject(t, t.TempDir(), "Migration")
+	require.NoError(t, s.OpenProject(ifacePath))
+
+	path, err := s.profileConfigPath(ifacePath, "old")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	original := `{"controller": {"name": "", "type": ""}, "resource": "", "task": []}`
+	require.NoError(t, os.WriteFile(path, []byte(original), 0644))
+
+	config, err := s.LoadProfile("old")
+	require.NoError(t, err)
+	require.Equal(t, ConfigVersion, config.ConfigVersion)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, original, string(data))
+
+	// the migration is stored once the profile is active
+	require.NoError(t, s.SwitchProfile("old"))
+	backup, err := os.ReadFile(path + ".v0.bak")
+	require.NoError(t, err)
+	require.Equal(t, original, string(backup))
+	saved, _, err := readConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, ConfigVersion, saved.ConfigVersion)
+}
@@ -0,0 +1,269 @@
+package pi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"muu-alpha/backend/events"
+	"muu-alpha/backend/safefile"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// MaxRecentProjects is the number of projects remembered in the registry
+const MaxRecentProjects = 10
+
+// Project is a project interface opened before
+type Project struct {
+	Path     string    `json:"path"` // absolute path of interface.json
+	Name     string    `json:"name"`
+	Label    string    `json:"label,omitempty"`
+	OpenedAt time.Time `json:"opened_at"`
+
+	LanguageFallback map[string][]string `json:"language_fallback,omitempty"` // per-language fallback chains
+	Profile          string              `json:"profile,omitempty"`           // active config profile
+}
+
+// projectRegistry is the content of projects.json
+type projectRegistry struct {
+	Active string    `json:"active"`
+	Recent []Project `json:"recent"`
+}
+
+var (
+	startupProject string
+	projectGuard   func() error
+)
+
+// SetStartupProject sets the interface.json opened by Startup instead of the last active project
+func SetStartupProject(ifacePath string) {
+	startupProject = ifacePath
+}
+
+// SetProjectGuard sets a check that must pass before switching projects,
+// e.g. to refuse switching while tasks are running
+func SetProjectGuard(guard func() error) {
+	projectGuard = guard
+}
+
+// ActiveProjectPath returns the interface.json of the last active project,
+// or the one next to the executable when no project was opened yet
+func ActiveProjectPath() string {
+	return PI().activeProjectPath()
+}
+
+// activeProjectPath is ActiveProjectPath for s
+func (s *service) activeProjectPath() string {
+	registry, err := s.loadRegistry()
+	if err == nil && registry.Active != "" {
+		if _, err := os.Stat(registry.Active); err == nil {
+			return registry.Active
+		}
+	}
+	return filepath.Join(s.exeDir, "interface.json")
+}
+
+// ProjectConfigPath returns the config path of the project whose interface is at ifacePath.
+// The project next to the executable keeps the legacy config/interface_config.json,
+// other projects get their own directory under config/projects.
+func ProjectConfigPath(ifacePath string) string {
+	return PI().projectConfigPath(ifacePath)
+}
+
+// projectConfigPath is ProjectConfigPath for s
+func (s *service) projectConfigPath(ifacePath string) string {
+	if abs, err := filepath.Abs(ifacePath); err == nil {
+		ifacePath = abs
+	}
+	if filepath.Dir(ifacePath) == s.exeDir {
+		return filepath.Join(s.configDir, "interface_config.json")
+	}
+	return filepath.Join(s.projectDir(ifacePath), "interface_config.json")
+}
+
+// projectDir returns the directory holding the per-project files of a non-default project
+func (s *service) projectDir(ifacePath string) string {
+	sum := sha256.Sum256([]byte(ifacePath))
+	return filepath.Join(s.configDir, "projects", hex.EncodeToString(sum[:])[:16])
+}
+
+// loadRegistry loads projects.json, a missing file is an empty registry
+func (s *service) loadRegistry() (*projectRegistry, error) {
+	registry := &projectRegistry{Recent: []Project{}}
+
+	data, err := os.ReadFile(s.registryPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+		return registry, fmt.Errorf("read project registry failed: %w", err)
+	}
+	if err := json.Unmarshal(data, registry); err != nil {
+		return &projectRegistry{Recent: []Project{}}, fmt.Errorf("parse project registry failed: %w", err)
+	}
+	return registry, nil
+}
+
+// saveRegistry saves projects.json
+func (s *service) saveRegistry(registry *projectRegistry) error {
+	data, err := json.MarshalIndent(registry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal project registry failed: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.registryPath), 0755); err != nil {
+		return fmt.Errorf("create config directory failed: %w", err)
+	}
+	if err := safefile.WriteFile(s.registryPath, data, 0644); err != nil {
+		return fmt.Errorf("write project registry failed: %w", err)
+	}
+	return nil
+}
+
+// ==================== frontend exposed interfaces ====================
+
+// OpenProject loads the interface at ifacePath with its own config and makes it the active project.
+// The current project stays loaded when the new one fails to load.
+func (s *service) OpenProject(ifacePath string) error {
+	if projectGuard != nil {
+		if err := projectGuard(); err != nil {
+			return err
+		}
+	}
+
+	abs, err := filepath.Abs(ifacePath)
+	if err != nil {
+		return fmt.Errorf("resolve project path failed: %w", err)
+	}
+
+	profile := s.rememberedProfile(abs)
+	configPath, err := s.profileConfigPath(abs, profile)
+	if err != nil {
+		return err
+	}
+	if err := s.load(abs, configPath); err != nil {
+		return err
+	}
+	s.configMu.Lock()
+	s.profile = profile
+	s.configMu.Unlock()
+
+	iface := s.V2Loaded().Interface
+	project := Project{
+		Path:     abs,
+		Name:     iface.Name,
+		Label:    iface.Label,
+		OpenedAt: time.Now(),
+	}
+
+	s.registryMu.Lock()
+	defer s.registryMu.Unlock()
+
+	registry, err := s.loadRegistry()
+	if err != nil {
+		log.Printf("load project registry failed, starting a new one: %v", err)
+	}
+	registry.Active = abs
+	recent := []Project{project}
+	for _, p := range registry.Recent {
+		if p.Path != abs {
+			recent = append(recent, p)
+		} else {
+			recent[0].LanguageFallback = p.LanguageFallback
+			recent[0].Profile = p.Profile
+		}
+	}
+	if len(recent) > MaxRecentProjects {
+		recent = recent[:MaxRecentProjects]
+	}
+	registry.Recent = recent
+
+	if err := s.saveRegistry(registry); err != nil {
+		log.Printf("save project registry failed: %v", err)
+	}
+
+	events.Publish(events.ProjectChanged{
+		Path: project.Path,
+		Name: project.Name,
+	})
+	return nil
+}
+
+// BrowseProject asks the user for an interface.json and opens it.
+// Returns the chosen path, or an empty string when the dialog was cancelled.
+func (s *service) BrowseProject() (string, error) {
+	if s.ctx == nil {
+		return "", errors.New("no window to show the dialog in")
+	}
+
+	path, err := runtime.OpenFileDialog(s.ctx, runtime.OpenDialogOptions{
+		Title: "Open interface.json",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Project Interface (*.json)", Pattern: "*.json"},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	return path, s.OpenProject(path)
+}
+
+// GetActiveProject gets the project currently loaded
+func (s *service) GetActiveProject() *Project {
+	s.ifaceMu.RLock()
+	defer s.ifaceMu.RUnlock()
+
+	if s.ifacePath == "" || s.v2Loaded == nil || s.v2Loaded.Interface == nil {
+		return nil
+	}
+	return &Project{
+		Path:  s.ifacePath,
+		Name:  s.v2Loaded.Interface.Name,
+		Label: s.v2Loaded.Interface.Label,
+	}
+}
+
+// GetRecentProjects gets the recently opened projects, newest first
+func (s *service) GetRecentProjects() []Project {
+	s.registryMu.Lock()
+	defer s.registryMu.Unlock()
+
+	registry, err := s.loadRegistry()
+	if err != nil {
+		log.Printf("load project registry failed: %v", err)
+	}
+	sort.SliceStable(registry.Recent, func(i, j int) bool {
+		return registry.Recent[i].OpenedAt.After(registry.Recent[j].OpenedAt)
+	})
+	return registry.Recent
+}
+
+// RemoveRecentProject forgets a project, its config files are kept
+func (s *service) RemoveRecentProject(ifacePath string) error {
+	s.registryMu.Lock()
+	defer s.registryMu.Unlock()
+
+	registry, err := s.loadRegistry()
+	if err != nil {
+		return err
+	}
+	if registry.Active == ifacePath {
+		return errors.New("cannot remove the active project")
+	}
+
+	recent := []Project{}
+	for _, p := range registry.Recent {
+		if p.Path != ifacePath {
+			recent = append(recent, p)
+		}
+	}
+	registry.Recent = recent
+	return s.saveRegistry(registry)
+}
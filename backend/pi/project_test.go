@@ -0,0 +1,92 @@
+package pi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestService creates a service of its own with the per-installation files in a temp directory
+func newTestService(t *testing.T) *service {
+	return newService(t.TempDir())
+}
+
+// openTestProject creates a service with an interface of the given content opened as its project,
+// it returns the service and the path of the interface
+func openTestProject(t *testing.T, iface string) (*service, string) {
+	s := newTestService(t)
+	ifacePath := filepath.Join(t.TempDir(), "interface.json")
+	require.NoError(t, os.WriteFile(ifacePath, []byte(iface), 0644))
+	require.NoError(t, s.OpenProject(ifacePath))
+	return s, ifacePath
+}
+
+func writeProject(t *testing.T, dir string, name string) string {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	path := filepath.Join(dir, "interface.json")
+	content := `{"interface_version": 2, "name": "` + name + `", "task": [{"name": "A", "entry": "A"}]}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestProjectConfigPath(t *testing.T) {
+	s := newTestService(t)
+
+	defaultPath := s.projectConfigPath(filepath.Join(s.exeDir, "interface.json"))
+	require.Equal(t, filepath.Join(s.configDir, "interface_config.json"), defaultPath)
+
+	otherDir := t.TempDir()
+	first := s.projectConfigPath(filepath.Join(otherDir, "a", "interface.json"))
+	second := s.projectConfigPath(filepath.Join(otherDir, "b", "interface.json"))
+	require.NotEqual(t, first, second)
+	require.Equal(t, filepath.Join(s.configDir, "projects"), filepath.Dir(filepath.Dir(first)))
+}
+
+func TestOpenProject(t *testing.T) {
+	s := newTestService(t)
+	projectsDir := t.TempDir()
+
+	first := writeProject(t, filepath.Join(projectsDir, "first"), "First")
+	second := writeProject(t, filepath.Join(projectsDir, "second"), "Second")
+
+	require.NoError(t, s.OpenProject(first))
+	require.NoError(t, s.OpenProject(second))
+	require.Equal(t, "Second", s.GetActiveProject().Name)
+	require.Equal(t, second, s.activeProjectPath())
+
+	// each project has its own config file
+	_, err := os.Stat(s.projectConfigPath(first))
+	require.NoError(t, err)
+	_, err = os.Stat(s.projectConfigPath(second))
+	require.NoError(t, err)
+
+	recent := s.GetRecentProjects()
+	require.Equal(t, 2, len(recent))
+	require.Equal(t, second, recent[0].Path)
+
+	t.Run("broken project keeps the current one", func(t *testing.T) {
+		broken := filepath.Join(projectsDir, "broken", "interface.json")
+		require.NoError(t, os.MkdirAll(filepath.Dir(broken), 0755))
+		require.NoError(t, os.WriteFile(broken, []byte(`{"interface_version": 2}`), 0644))
+
+		require.Error(t, s.OpenProject(broken))
+		require.Equal(t, "Second", s.GetActiveProject().Name)
+		require.Equal(t, 2, len(s.GetRecentProjects()))
+	})
+
+	t.Run("guard refuses switching", func(t *testing.T) {
+		SetProjectGuard(func() error { return os.ErrPermission })
+		defer SetProjectGuard(nil)
+
+		require.Error(t, s.OpenProject(first))
+		require.Equal(t, "Second", s.GetActiveProject().Name)
+	})
+
+	t.Run("remove recent project", func(t *testing.T) {
+		require.Error(t, s.RemoveRecentProject(second))
+		require.NoError(t, s.RemoveRecentProject(first))
+		require.Equal(t, 1, len(s.GetRecentProjects()))
+	})
+}
@@ -0,0 +1,174 @@
+package pi
+
+import (
+	"fmt"
+	"log"
+	"muu-alpha/backend/events"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// newConfigSync creates an empty report, lists are never nil for the frontend
+func newConfigSync() events.ConfigSync {
+	return events.ConfigSync{
+		Renamed:  []events.TaskRename{},
+		Orphaned: []events.TaskRef{},
+		Added:    []events.TaskRef{},
+		Reset:    []events.InputReset{},
+		Cases:    []events.CaseReset{},
+	}
+}
+
+// taskAliases maps the aliases of the interface tasks to their current names.
+// Aliases that are the name of a task are ignored, the task itself wins.
+func taskAliases(iface *V2Interface) map[string]string {
+	aliases := make(map[string]string)
+	for _, task := range iface.Task {
+		for _, alias := range task.Aliases {
+			if _, ok := aliases[alias]; !ok && findTask(iface, alias) == nil {
+				aliases[alias] = task.Name
+			}
+		}
+	}
+	return aliases
+}
+
+// reconcileSelections resets a controller or resource that no longer exists to the first one.
+// Returns true if any changes were made.
+func reconcileSelections(config *InterfaceConfig, iface *V2Interface, report *events.ConfigSync) bool {
+	changed := false
+
+	if len(iface.Controller) > 0 {
+		if ctrl := findController(iface, config.Controller.Name); ctrl == nil {
+			first := iface.Controller[0]
+			if config.Controller.Name != "" {
+				report.Controller = &events.SelectionReset{From: config.Controller.Name, To: first.Name}
+			}
+			config.Controller = ConfigController{Name: first.Name, Type: first.Type}
+			changed = true
+		} else if ctrl.Type != config.Controller.Type {
+			config.Controller.Type = ctrl.Type
+			changed = true
+		}
+	}
+
+	if len(iface.Resource) > 0 && findResource(iface, config.Resource) == nil {
+		first := iface.Resource[0].Name
+		if config.Resource != "" {
+			report.Resource = &events.SelectionReset{From: config.Resource, To: first}
+		}
+		config.Resource = first
+		changed = true
+	}
+
+	return changed
+}
+
+// reconcileTasks renames tasks through the aliases, reports the tasks the interface no longer has
+// and appends the default_check tasks added since the last sync.
+// Returns true if any changes were made.
+func (s *service) reconcileTasks(config *InterfaceConfig, iface *V2Interface, report *events.ConfigSync) bool {
+	changed := false
+	aliases := taskAliases(iface)
+
+	inConfig := make(map[string]bool)
+	for i := range config.Task {
+		task := &config.Task[i]
+		if findTask(iface, task.Name) == nil {
+			if name, ok := aliases[task.Name]; ok {
+				report.Renamed = append(report.Renamed, events.TaskRename{ID: task.ID, From: task.Name, To: name})
+				task.Name = name
+				changed = true
+			} else {
+				report.Orphaned = append(report.Orphaned, events.TaskRef{ID: task.ID, Name: task.Name})
+			}
+		}
+		inConfig[task.Name] = true
+	}
+
+	// a config without known tasks predates them, only tasks added from now on are appended
+	if config.KnownTasks != nil {
+		known := make(map[string]bool)
+		for _, name := range config.KnownTasks {
+			if renamed, ok := aliases[name]; ok {
+				name = renamed
+			}
+			known[name] = true
+		}
+		for _, piTask := range iface.Task {
+			if known[piTask.Name] || inConfig[piTask.Name] || !piTask.DefaultCheck {
+				continue
+			}
+			task := ConfigTask{
+				ID:      uuid.New().String(),
+				Name:    piTask.Name,
+				Checked: true,
+				Option:  s.initTaskOptions(piTask.Option, iface.Option),
+			}
+			config.Task = append(config.Task, task)
+			report.Added = append(report.Added, events.TaskRef{ID: task.ID, Name: task.Name})
+			inConfig[task.Name] = true
+			changed = true
+		}
+	}
+
+	knownTasks := interfaceTaskNames(iface)
+	if !slices.Equal(knownTasks, config.KnownTasks) {
+		config.KnownTasks = knownTasks
+		changed = true
+	}
+
+	return changed
+}
+
+// interfaceTaskNames returns the names of the tasks of iface, never nil
+func interfaceTaskNames(iface *V2Interface) []string {
+	names := []string{}
+	for _, task := range iface.Task {
+		names = append(names, task.Name)
+	}
+	return names
+}
+
+// notifySync tells the user about the tasks and selections changed by a sync
+func notifySync(report events.ConfigSync) {
+	if report.Empty() {
+		return
+	}
+	log.Printf("config synced with the interface: %+v", report)
+
+	if len(report.Orphaned) > 0 {
+		names := []string{}
+		for _, task := range report.Orphaned {
+			names = append(names, task.Name)
+		}
+		events.Publish(events.Warn(fmt.Sprintf("tasks no longer in the interface: %s", strings.Join(names, ", "))))
+	}
+	for _, reset := range report.Reset {
+		events.Publish(events.Warn(fmt.Sprintf("invalid value of %s.%s in task %s reset to default: %s",
+			reset.Option, reset.Input, reset.Task.Name, reset.Reason)))
+	}
+	for _, reset := range report.Cases {
+		events.Publish(events.Warn(fmt.Sprintf("unknown case %s of %s in task %s reset", reset.Case, reset.Option, reset.Task.Name)))
+	}
+	if report.Controller != nil {
+		events.Publish(events.Warn(fmt.Sprintf("controller %s no longer exists, using %s", report.Controller.From, report.Controller.To)))
+	}
+	if report.Resource != nil {
+		events.Publish(events.Warn(fmt.Sprintf("resource %s no longer exists, using %s", report.Resource.From, report.Resource.To)))
+	}
+}
+
+// ==================== frontend exposed interfaces ====================
+
+// GetConfigSync gets how the config was reconciled with the interface when it was last loaded or reloaded
+func (s *service) GetConfigSync() events.ConfigSync {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	if s.lastSync.Renamed == nil {
+		return newConfigSync()
+	}
+	return s.lastSync
+}
@@ -0,0 +1,188 @@
+package pi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"muu-alpha/backend/events"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	ifacePath := filepath.Join(tmpDir, "interface.json")
+	configPath := filepath.Join(tmpDir, "interface_config.json")
+
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(ifacePath, []byte(content), 0644))
+	}
+
+	write(`{
+		"interface_version": 2,
+		"name": "Test",
+		"controller": [{"name": "Phone", "type": "Adb"}, {"name": "Tablet", "type": "Adb"}],
+		"resource": [{"name": "Official", "path": ["res"]}, {"name": "Global", "path": ["global"]}],
+		"task": [
+			{"name": "Daily", "entry": "Daily", "default_check": true},
+			{"name": "Shop", "entry": "Shop"},
+			{"name": "Gone", "entry": "Gone"}
+		]
+	}`)
+	s := newTestService(t)
+	require.NoError(t, s.load(ifacePath, configPath))
+
+	config := s.GetConfig()
+	require.Equal(t, []string{"Daily", "Shop", "Gone"}, config.KnownTasks)
+	revision, err := s.SetController(s.GetRevision(), "Tablet")
+	require.NoError(t, err)
+	revision, err = s.SetResource(revision, "Global")
+	require.NoError(t, err)
+	// the user removed the default task
+	_, err = s.RemoveTask(revision, config.Task[0].ID)
+	require.NoError(t, err)
+
+	var got []events.InterfaceReloaded
+	unsubscribe := events.Subscribe(func(e events.Event) {
+		if reloaded, ok := e.(events.InterfaceReloaded); ok {
+			got = append(got, reloaded)
+		}
+	})
+	defer unsubscribe()
+
+	write(`{
+		"interface_version": 2,
+		"name": "Test",
+		"controller": [{"name": "Phone", "type": "Adb"}],
+		"resource": [{"name": "Official", "path": ["res"]}, {"name": "Global", "path": ["global"]}],
+		"task": [
+			{"name": "Daily", "entry": "Daily", "default_check": true},
+			{"name": "Store", "entry": "Shop", "aliases": ["Shop"]},
+			{"name": "Event", "entry": "Event", "default_check": true}
+		]
+	}`)
+	require.NoError(t, s.Reload())
+	require.Equal(t, 1, len(got))
+	require.True(t, got[0].ConfigChanged)
+
+	report := got[0].Sync
+	require.Equal(t, report, s.GetConfigSync())
+	require.Len(t, report.Renamed, 1)
+	require.Equal(t, "Shop", report.Renamed[0].From)
+	require.Equal(t, "Store", report.Renamed[0].To)
+	require.Len(t, report.Orphaned, 1)
+	require.Equal(t, "Gone", report.Orphaned[0].Name)
+	require.Len(t, report.Added, 1)
+	require.Equal(t, "Event", report.Added[0].Name)
+	require.Equal(t, &events.SelectionReset{From: "Tablet", To: "Phone"}, report.Controller)
+	require.Nil(t, report.Resource)
+
+	// the removed default task stays removed, the orphaned task is kept for the user to delete
+	config = s.GetConfig()
+	require.Equal(t, []string{"Store", "Gone", "Event"}, taskNames(config))
+	require.True(t, config.Task[2].Checked)
+	require.Equal(t, "Phone", config.Controller.Name)
+	require.Equal(t, "Global", config.Resource)
+	require.Equal(t, []string{"Daily", "Store", "Event"}, config.KnownTasks)
+
+	// a second sync has nothing left to do
+	report, changed := s.syncConfigOptions()
+	require.False(t, changed)
+	require.Empty(t, report.Renamed)
+	require.Empty(t, report.Added)
+	require.Len(t, report.Orphaned, 1)
+}
+
+func TestValidateAliases(t *testing.T) {
+	data := `{
+		"interface_version": 2,
+		"name": "Test",
+		"task": [
+			{"name": "A", "entry": "A", "aliases": ["B", "Old"]},
+			{"name": "B", "entry": "B", "aliases": ["Old"]}
+		]
+	}`
+	iface, err := decodeV2([]byte(data))
+	require.NoError(t, err)
+
+	report := ValidateV2(iface)
+	require.Equal(t, []Issue{
+		{Path: "task[0].aliases[0]", Severity: SeverityError, Message: "alias is the name of a task: B"},
+		{Path: "task[1].aliases[0]", Severity: SeverityError, Message: "alias already used by task A: Old"},
+	}, report.Errors())
+}
+
+func TestSyncResetsInvalidInputValues(t *testing.T) {
+	s := newTestService(t)
+	dir := t.TempDir()
+	ifacePath := filepath.Join(dir, "interface.json")
+	require.NoError(t, os.WriteFile(ifacePath, []byte(`{
+		"interface_version": 2,
+		"name": "Invalid",
+		"task": [{"name": "A", "entry": "A", "option": ["Times"]}],
+		"option": {
+			"Times": {"type": "input", "inputs": [
+				{"name": "count", "kind": "int", "min": 1, "max": 9, "default": "3"},
+				{"name": "code", "verify": "^[A-Z]+$", "default": "CN"},
+				{"name": "at", "kind": "time"}
+			]}
+		}
+	}`), 0644))
+	configPath := filepath.Join(dir, "interface_config.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{
+		"config_version": 2,
+		"controller": {"name": "", "type": ""},
+		"resource": "",
+		"task": [{"id": "1", "name": "A", "checked": true, "option": [
+			{"name": "Times", "value": {"count": 42, "code": "cn", "at": "04:00"}}
+		]}]
+	}`), 0644))
+	require.NoError(t, s.load(ifacePath, configPath))
+
+	report := s.GetConfigSync()
+	require.Len(t, report.Reset, 2)
+	require.Equal(t, "count", report.Reset[0].Input)
+	require.Equal(t, 42.0, report.Reset[0].Value)
+	require.Equal(t, "code", report.Reset[1].Input)
+	require.Equal(t, events.TaskRef{ID: "1", Name: "A"}, report.Reset[1].Task)
+
+	require.Equal(t, []ConfigTaskOption{
+		{Name: "Times", Value: map[string]interface{}{"count": 3, "code": "CN", "at": "04:00"}},
+	}, s.GetConfig().Task[0].Option)
+}
+
+func TestSyncResetsUnknownCases(t *testing.T) {
+	s := newTestService(t)
+	dir := t.TempDir()
+	ifacePath := filepath.Join(dir, "interface.json")
+	require.NoError(t, os.WriteFile(ifacePath, []byte(`{
+		"interface_version": 2,
+		"name": "Cases",
+		"task": [{"name": "A", "entry": "A", "option": ["Mode", "Stages"]}],
+		"option": {
+			"Mode": {"cases": [{"name": "Fast"}, {"name": "Slow"}], "default_case": "Slow"},
+			"Stages": {"type": "checkbox", "cases": [{"name": "1-7"}, {"name": "3-1"}]}
+		}
+	}`), 0644))
+	configPath := filepath.Join(dir, "interface_config.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{
+		"config_version": 2,
+		"controller": {"name": "", "type": ""},
+		"resource": "",
+		"task": [{"id": "1", "name": "A", "checked": true, "option": [
+			{"name": "Mode", "value": "Turbo"},
+			{"name": "Stages", "value": ["1-7", "9-9"]}
+		]}]
+	}`), 0644))
+	require.NoError(t, s.load(ifacePath, configPath))
+
+	require.Equal(t, []events.CaseReset{
+		{Task: events.TaskRef{ID: "1", Name: "A"}, Option: "Mode", Case: "Turbo"},
+		{Task: events.TaskRef{ID: "1", Name: "A"}, Option: "Stages", Case: "9-9"},
+	}, s.GetConfigSync().Cases)
+	require.Equal(t, []ConfigTaskOption{
+		{Name: "Stages", Value: []string{"1-7"}},
+		{Name: "Mode", Value: "Slow"},
+	}, s.GetConfig().Task[0].Option)
+}
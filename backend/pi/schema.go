@@ -0,0 +1,98 @@
+package pi
+
+import (
+	"fmt"
+	"muu-alpha/backend/jsonc"
+	"muu-alpha/backend/schema"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	InterfaceSchemaFile = "interface.schema.json"
+	ConfigSchemaFile    = "interface_config.schema.json"
+)
+
+var (
+	schemaOnce      sync.Once
+	interfaceSchema *schema.Schema
+	configSchema    *schema.Schema
+)
+
+func initSchemas() {
+	schemaOnce.Do(func() {
+		interfaceSchema = schema.Generate(V2Interface{}, "MaaFramework Project Interface V2")
+		configSchema = schema.Generate(InterfaceConfig{}, "MUU Interface Config")
+
+		// editors reference the schema from the file itself
+		for _, s := range []*schema.Schema{interfaceSchema, configSchema} {
+			s.Properties["$schema"] = &schema.Schema{Type: "string"}
+		}
+	})
+}
+
+// InterfaceSchema returns the JSON Schema of interface.json, generated from V2Interface
+func InterfaceSchema() *schema.Schema {
+	initSchemas()
+	return interfaceSchema
+}
+
+// ConfigSchema returns the JSON Schema of interface_config.json, generated from InterfaceConfig
+func ConfigSchema() *schema.Schema {
+	initSchemas()
+	return configSchema
+}
+
+// Schemas returns every schema by file name
+func Schemas() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		InterfaceSchemaFile: InterfaceSchema(),
+		ConfigSchemaFile:    ConfigSchema(),
+	}
+}
+
+// WriteSchemas writes every schema to dir and returns the paths written, in name order
+func WriteSchemas(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create output directory failed: %w", err)
+	}
+
+	schemas := Schemas()
+	paths := []string{}
+	for _, name := range sortedKeys(schemas) {
+		data, err := schemas[name].MarshalIndent()
+		if err != nil {
+			return paths, fmt.Errorf("marshal %s failed: %w", name, err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return paths, fmt.Errorf("write %s failed: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// validateSchema checks data against s and converts the mismatches into issues.
+// Unknown properties are warnings since other tools may add their own fields.
+func validateSchema(s *schema.Schema, data []byte) []Issue {
+	var v interface{}
+	if err := jsonc.Unmarshal(data, &v); err != nil {
+		return []Issue{{Severity: SeverityError, Message: err.Error()}}
+	}
+
+	issues := []Issue{}
+	for _, e := range s.Validate(v) {
+		severity := SeverityError
+		if e.Kind == schema.KindAdditional {
+			severity = SeverityWarning
+		}
+		issues = append(issues, Issue{
+			Path:     e.Path,
+			Severity: severity,
+			Message:  e.Message,
+		})
+	}
+	return issues
+}
@@ -0,0 +1,42 @@
+package pi
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The schema files shipped in the repository must match the Go types; run `go generate` after changing them
+func TestSchemaFilesUpToDate(t *testing.T) {
+	for name, s := range Schemas() {
+		t.Run(name, func(t *testing.T) {
+			want, err := s.MarshalIndent()
+			require.NoError(t, err)
+
+			got, err := os.ReadFile(filepath.Join("..", "..", "schema", name))
+			require.NoError(t, err)
+			require.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+func TestDefaultConfigMatchesSchema(t *testing.T) {
+	iface, err := ParseV2([]byte(`{
+		"interface_version": 2,
+		"name": "Test",
+		"controller": [{"name": "Emulator", "type": "Adb"}],
+		"resource": [{"name": "Official", "path": ["./resource"]}],
+		"task": [{"name": "Task", "entry": "Task", "default_check": true, "option": ["Mode"]}],
+		"option": {"Mode": {"cases": [{"name": "A"}, {"name": "B"}]}}
+	}`))
+	require.NoError(t, err)
+
+	s := &service{v2Loaded: &V2Loaded{Interface: iface}}
+	s.initDefaultConfig()
+	data, err := json.Marshal(s.config)
+	require.NoError(t, err)
+	require.Empty(t, validateSchema(ConfigSchema(), data))
+}
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"muu-alpha/backend/events"
+	"muu-alpha/backend/migrate"
+	"muu-alpha/backend/safefile"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -20,37 +23,81 @@ type service struct {
 	ctx        context.Context
 	version    Version
 	v2Loaded   *V2Loaded
+	ifacePath  string
+	ifaceMu    sync.RWMutex
 	config     *InterfaceConfig
 	configPath string
+	profile    string
+	revision   int64             // incremented on every change of config
+	lastSync   events.ConfigSync // how config was reconciled with the interface
+	undo       *undoHistory      // undo and redo stack of config
 	configMu   sync.RWMutex
+
+	saveMu        sync.Mutex // serializes saves, so an older revision never overwrites a newer one
+	savedPath     string     // config file written by the last save
+	savedRevision int64      // revision written by the last save
+
+	exeDir       string
+	configDir    string
+	registryPath string
+	registryMu   sync.Mutex
+
+	contentCache map[string]string // URL -> content fetched for the loaded interface
+	contentMu    sync.Mutex
 }
 
 func (s *service) GetVersion() int {
+	s.ifaceMu.RLock()
+	defer s.ifaceMu.RUnlock()
 	return int(s.version)
 }
 
 func (s *service) V2Loaded() *V2Loaded {
+	s.ifaceMu.RLock()
+	defer s.ifaceMu.RUnlock()
 	return s.v2Loaded
 }
 
+// setLoaded replaces the loaded interface
+func (s *service) setLoaded(version Version, v2Loaded *V2Loaded, ifacePath string) {
+	s.ifaceMu.Lock()
+	defer s.ifaceMu.Unlock()
+	s.version = version
+	s.v2Loaded = v2Loaded
+	s.ifacePath = ifacePath
+
+	// a reload may change the documents, fetch them again
+	s.contentMu.Lock()
+	s.contentCache = nil
+	s.contentMu.Unlock()
+}
+
 // initDefaultConfig initializes default config from PI data
 func (s *service) initDefaultConfig() {
 	s.configMu.Lock()
 	defer s.configMu.Unlock()
 
+	s.config = s.defaultConfig()
+	s.revision++
+	s.resetUndo()
+}
+
+// defaultConfig creates the default config from PI data
+func (s *service) defaultConfig() *InterfaceConfig {
 	config := &InterfaceConfig{
-		Controller: ConfigController{},
-		Resource:   "",
-		Task:       []ConfigTask{},
+		ConfigVersion: ConfigVersion,
+		Controller:    ConfigController{},
+		Resource:      "",
+		Task:          []ConfigTask{},
 	}
 
 	// if v2 data is not loaded, use empty config
-	if s.v2Loaded == nil || s.v2Loaded.Interface == nil {
-		s.config = config
-		return
+	v2Loaded := s.V2Loaded()
+	if v2Loaded == nil || v2Loaded.Interface == nil {
+		return config
 	}
 
-	iface := s.v2Loaded.Interface
+	iface := v2Loaded.Interface
 
 	// use the first controller
 	if len(iface.Controller) > 0 {
@@ -66,6 +113,8 @@ func (s *service) initDefaultConfig() {
 		config.Resource = iface.Resource[0].Name
 	}
 
+	config.KnownTasks = interfaceTaskNames(iface)
+
 	// add all tasks, set DefaultCheck to checked
 	for _, task := range iface.Task {
 		// Initialize options with default values
@@ -79,188 +128,174 @@ func (s *service) initDefaultConfig() {
 		})
 	}
 
-	s.config = config
+	return config
 }
 
-// initTaskOptions initializes option values for a task
+// initTaskOptions initializes option values for a task, hidden options included
 func (s *service) initTaskOptions(optionNames []string, optionDefs map[string]V2Option) []ConfigTaskOption {
 	if len(optionNames) == 0 || optionDefs == nil {
 		return []ConfigTaskOption{}
 	}
 
-	options := []ConfigTaskOption{}
-	s.collectOptionDefaults(&options, optionNames, optionDefs)
-	return options
+	return getExpectedOptions(optionNames, map[string]interface{}{}, optionDefs, storedScope())
 }
 
-// collectOptionDefaults recursively collects default values for options
-func (s *service) collectOptionDefaults(options *[]ConfigTaskOption, optionNames []string, optionDefs map[string]V2Option) {
-	for _, optName := range optionNames {
-		optDef, exists := optionDefs[optName]
-		if !exists {
-			continue
+// uniqueOptions keeps the first of options with the same name,
+// the cases of a checkbox can nest the same option
+func uniqueOptions(options []ConfigTaskOption) []ConfigTaskOption {
+	seen := make(map[string]bool, len(options))
+	unique := options[:0]
+	for _, opt := range options {
+		if !seen[opt.Name] {
+			seen[opt.Name] = true
+			unique = append(unique, opt)
 		}
+	}
+	return unique
+}
 
-		optType := optDef.GetType()
-
-		switch optType {
-		case "select":
-			// For select type, use default_case or first case
-			var selectedValue string
-			var selectedCase *V2OptionCase
-
-			if optDef.DefaultCase != "" {
-				selectedValue = optDef.DefaultCase
-				for i := range optDef.Cases {
-					if optDef.Cases[i].Name == optDef.DefaultCase {
-						selectedCase = &optDef.Cases[i]
-						break
-					}
-				}
-			} else if len(optDef.Cases) > 0 {
-				selectedValue = optDef.Cases[0].Name
-				selectedCase = &optDef.Cases[0]
-			}
-
-			if selectedValue != "" {
-				*options = append(*options, ConfigTaskOption{
-					Name:  optName,
-					Value: selectedValue,
-				})
-			}
+// loadConfig loads config from file
+func (s *service) loadConfig() error {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
 
-			// Recursively collect nested options
-			if selectedCase != nil && len(selectedCase.Option) > 0 {
-				s.collectOptionDefaults(options, selectedCase.Option, optionDefs)
-			}
+	config, from, err := readConfig(s.configPath)
+	if err != nil {
+		return err
+	}
+	if err := persistMigration(s.configPath, config, from); err != nil {
+		return err
+	}
 
-		case "switch":
-			// For switch type, default to No case
-			var selectedValue string
-			var selectedCase *V2OptionCase
+	s.config = config
+	s.revision++
+	s.resetUndo()
+	return nil
+}
 
-			for i := range optDef.Cases {
-				caseName := optDef.Cases[i].Name
-				if caseName != "Yes" && caseName != "yes" && caseName != "Y" && caseName != "y" {
-					selectedValue = caseName
-					selectedCase = &optDef.Cases[i]
-					break
-				}
-			}
+// readConfig reads a config file, schema mismatches are only logged.
+// Older files are migrated in memory, the version the file has is returned for persistMigration.
+func readConfig(path string) (*InterfaceConfig, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read config file failed: %w", err)
+	}
 
-			if selectedValue == "" && len(optDef.Cases) > 0 {
-				selectedValue = "No"
-			}
+	migrated, from, err := configMigrations.Migrate(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("parse config file failed: %w", err)
+	}
 
-			if selectedValue != "" {
-				*options = append(*options, ConfigTaskOption{
-					Name:  optName,
-					Value: selectedValue,
-				})
-			}
+	var config InterfaceConfig
+	if err := json.Unmarshal(migrated, &config); err != nil {
+		return nil, 0, fmt.Errorf("parse config file failed: %w", err)
+	}
 
-			// Recursively collect nested options
-			if selectedCase != nil && len(selectedCase.Option) > 0 {
-				s.collectOptionDefaults(options, selectedCase.Option, optionDefs)
-			}
+	for _, issue := range validateSchema(ConfigSchema(), migrated) {
+		log.Printf("config %s: %s", path, issue)
+	}
+	if from > ConfigVersion {
+		log.Printf("config %s has version %d, newer than %d, unknown fields are kept", path, from, ConfigVersion)
+	}
 
-		case "input":
-			// For input type, set default values for all inputs
-			for _, input := range optDef.Inputs {
-				value := input.Default
-				if value == "" {
-					// Use type-based default
-					switch input.PipelineType {
-					case "bool":
-						value = "false"
-					case "int":
-						value = "0"
-					default:
-						value = ""
-					}
-				}
+	return &config, from, nil
+}
 
-				*options = append(*options, ConfigTaskOption{
-					Name:  optName + "." + input.Name,
-					Value: value,
-				})
-			}
-		}
+// persistMigration rewrites the config file at path read from version from, if it was migrated.
+// The original is kept as a backup.
+func persistMigration(path string, config *InterfaceConfig, from int) error {
+	if from >= ConfigVersion {
+		return nil
+	}
+	backup, err := migrate.Backup(path, from)
+	if err != nil {
+		return fmt.Errorf("backup config before migration failed: %w", err)
 	}
+	log.Printf("config %s migrated from version %d to %d, backup at %s", path, from, ConfigVersion, backup)
+	return writeConfig(path, config)
 }
 
-// loadConfig loads config from file
-func (s *service) loadConfig() error {
+// syncConfigOptions syncs the config with PI definitions and keeps the report for GetConfigSync.
+// Returns true if any changes were made
+func (s *service) syncConfigOptions() (events.ConfigSync, bool) {
 	s.configMu.Lock()
 	defer s.configMu.Unlock()
 
-	data, err := os.ReadFile(s.configPath)
-	if err != nil {
-		return fmt.Errorf("read config file failed: %w", err)
-	}
-
-	var config InterfaceConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return fmt.Errorf("parse config file failed: %w", err)
+	report, changed := s.syncConfig(s.config)
+	s.lastSync = report
+	if changed {
+		s.revision++
+		// syncing is not a change of the user, it cannot be undone
+		s.undoHistoryLocked().baseline = cloneConfig(s.config)
 	}
-
-	s.config = &config
-	return nil
+	return report, changed
 }
 
-// syncConfigOptions syncs options in config with PI definitions
+// syncConfig reconciles the controller, resource and tasks of config with PI definitions
+// and syncs the options of every task.
 // Returns true if any changes were made
-func (s *service) syncConfigOptions() bool {
-	if s.config == nil || s.v2Loaded == nil || s.v2Loaded.Interface == nil {
-		return false
+func (s *service) syncConfig(config *InterfaceConfig) (events.ConfigSync, bool) {
+	report := newConfigSync()
+	v2Loaded := s.V2Loaded()
+	if config == nil || v2Loaded == nil || v2Loaded.Interface == nil {
+		return report, false
 	}
 
-	s.configMu.Lock()
-	defer s.configMu.Unlock()
+	iface := v2Loaded.Interface
+	changed := reconcileSelections(config, iface, &report)
+	if s.reconcileTasks(config, iface, &report) {
+		changed = true
+	}
 
-	iface := s.v2Loaded.Interface
-	changed := false
+	if s.syncTaskOptions(config, iface, &report) {
+		changed = true
+	}
 
-	for i := range s.config.Task {
-		task := &s.config.Task[i]
+	return report, changed
+}
 
-		// Find corresponding PI task
-		var piTask *V2Task
-		for j := range iface.Task {
-			if iface.Task[j].Name == task.Name {
-				piTask = &iface.Task[j]
-				break
-			}
-		}
+// syncTaskOptions syncs the options of every task of config with PI definitions,
+// invalid input values are added to report. Returns true if any changes were made
+func (s *service) syncTaskOptions(config *InterfaceConfig, iface *V2Interface, report *events.ConfigSync) bool {
+	changed := false
+	for i := range config.Task {
+		task := &config.Task[i]
 
+		// Find corresponding PI task, orphaned tasks keep their options
+		piTask := findTask(iface, task.Name)
 		if piTask == nil {
 			continue
 		}
 
-		// Sync options for this task
-		if s.syncTaskConfigOptions(task, piTask.Option, iface.Option) {
+		// Sync options for this task, values of config version 1 store every input as a separate option,
+		// they are grouped first so they can be checked
+		options, grouped := groupInputValues(task.Option, iface.Option)
+		task.Option = options
+		cases := resetUnknownCases(task, iface.Option)
+		report.Cases = append(report.Cases, cases...)
+		report.Reset = append(report.Reset, invalidInputValues(task, iface.Option)...)
+		if s.syncTaskConfigOptions(task, piTask.Option, iface.Option) || grouped || len(cases) > 0 {
 			changed = true
 		}
 	}
-
 	return changed
 }
 
-// syncTaskConfigOptions syncs options for a single task
-// Returns true if any changes were made
+// syncTaskConfigOptions syncs options for a single task, hidden options keep their values.
+// Input values must be grouped. Returns true if any changes were made
 func (s *service) syncTaskConfigOptions(task *ConfigTask, optionNames []string, optionDefs map[string]V2Option) bool {
 	if optionDefs == nil {
 		return false
 	}
 
 	// Build map of current options for quick lookup
-	currentOptions := make(map[string]string)
+	currentOptions := make(map[string]interface{})
 	for _, opt := range task.Option {
 		currentOptions[opt.Name] = opt.Value
 	}
 
 	// Get expected options based on current selections
-	expectedOptions := s.getExpectedOptions(optionNames, currentOptions, optionDefs)
+	expectedOptions := getExpectedOptions(optionNames, currentOptions, optionDefs, storedScope())
 
 	// Check for missing options
 	missingOptions := []ConfigTaskOption{}
@@ -283,8 +318,20 @@ func (s *service) syncTaskConfigOptions(task *ConfigTask, optionNames []string,
 		}
 	}
 
+	// Values of kept options are converted to the types of their definition
+	normalized := make(map[string]interface{}, len(expectedOptions))
+	valuesChanged := false
+	for _, expected := range expectedOptions {
+		if current, exists := currentOptions[expected.Name]; exists {
+			normalized[expected.Name] = expected.Value
+			if !sameJSON(current, expected.Value) {
+				valuesChanged = true
+			}
+		}
+	}
+
 	// No changes needed
-	if len(missingOptions) == 0 && len(extraOptions) == 0 {
+	if len(missingOptions) == 0 && len(extraOptions) == 0 && !valuesChanged {
 		return false
 	}
 
@@ -294,6 +341,7 @@ func (s *service) syncTaskConfigOptions(task *ConfigTask, optionNames []string,
 	// Keep existing options that are expected
 	for _, opt := range task.Option {
 		if expectedNames[opt.Name] {
+			opt.Value = normalized[opt.Name]
 			newOptions = append(newOptions, opt)
 		}
 	}
@@ -305,18 +353,32 @@ func (s *service) syncTaskConfigOptions(task *ConfigTask, optionNames []string,
 	return true
 }
 
-// getExpectedOptions returns expected options based on current selections
-func (s *service) getExpectedOptions(optionNames []string, currentValues map[string]string, optionDefs map[string]V2Option) []ConfigTaskOption {
+// getExpectedOptions returns expected options based on current selections.
+// Conditions see the options shown by the previous pass until the shown options no longer change,
+// the first pass sees the current values.
+func getExpectedOptions(optionNames []string, currentValues map[string]interface{}, optionDefs map[string]V2Option, scope OptionScope) []ConfigTaskOption {
+	scope.Values = currentValues
 	expected := []ConfigTaskOption{}
-	s.collectExpectedOptions(&expected, optionNames, currentValues, optionDefs)
+	// conditions that contradict each other could change the shown options forever
+	for i := 0; i <= len(optionDefs); i++ {
+		expected = []ConfigTaskOption{}
+		collectExpectedOptions(&expected, optionNames, currentValues, optionDefs, scope)
+		expected = uniqueOptions(expected)
+
+		next := scope.withValues(expected)
+		if sameJSON(next.Values, scope.Values) {
+			break
+		}
+		scope = next
+	}
 	return expected
 }
 
-// collectExpectedOptions recursively collects expected options
-func (s *service) collectExpectedOptions(expected *[]ConfigTaskOption, optionNames []string, currentValues map[string]string, optionDefs map[string]V2Option) {
+// collectExpectedOptions recursively collects expected options, skipping the options hidden in scope
+func collectExpectedOptions(expected *[]ConfigTaskOption, optionNames []string, currentValues map[string]interface{}, optionDefs map[string]V2Option, scope OptionScope) {
 	for _, optName := range optionNames {
 		optDef, exists := optionDefs[optName]
-		if !exists {
+		if !exists || !optDef.When.Match(scope, optionDefs) {
 			continue
 		}
 
@@ -325,7 +387,7 @@ func (s *service) collectExpectedOptions(expected *[]ConfigTaskOption, optionNam
 		switch optType {
 		case "select":
 			// Get current or default value
-			selectedValue := currentValues[optName]
+			selectedValue := ConfigTaskOption{Value: currentValues[optName]}.CaseName()
 			var selectedCase *V2OptionCase
 
 			if selectedValue == "" {
@@ -354,12 +416,12 @@ func (s *service) collectExpectedOptions(expected *[]ConfigTaskOption, optionNam
 
 			// Recursively collect nested options
 			if selectedCase != nil && len(selectedCase.Option) > 0 {
-				s.collectExpectedOptions(expected, selectedCase.Option, currentValues, optionDefs)
+				collectExpectedOptions(expected, selectedCase.Option, currentValues, optionDefs, scope)
 			}
 
 		case "switch":
 			// Get current or default value
-			selectedValue := currentValues[optName]
+			selectedValue := ConfigTaskOption{Value: currentValues[optName]}.CaseName()
 			var selectedCase *V2OptionCase
 
 			if selectedValue == "" {
@@ -394,78 +456,178 @@ func (s *service) collectExpectedOptions(expected *[]ConfigTaskOption, optionNam
 
 			// Recursively collect nested options
 			if selectedCase != nil && len(selectedCase.Option) > 0 {
-				s.collectExpectedOptions(expected, selectedCase.Option, currentValues, optionDefs)
+				collectExpectedOptions(expected, selectedCase.Option, currentValues, optionDefs, scope)
 			}
 
-		case "input":
-			// For input type, add all input fields
-			for _, input := range optDef.Inputs {
-				key := optName + "." + input.Name
-				value := currentValues[key]
-
-				if value == "" {
-					// Use default
-					value = input.Default
-					if value == "" {
-						switch input.PipelineType {
-						case "bool":
-							value = "false"
-						case "int":
-							value = "0"
-						default:
-							value = ""
-						}
-					}
-				}
+		case "checkbox":
+			// Get current or default value
+			selectedValue := optDef.DefaultSelection()
+			if current, ok := currentValues[optName]; ok {
+				selectedValue = ConfigTaskOption{Value: current}.CaseNames()
+			}
 
-				*expected = append(*expected, ConfigTaskOption{
-					Name:  key,
-					Value: value,
-				})
+			*expected = append(*expected, ConfigTaskOption{
+				Name:  optName,
+				Value: selectedValue,
+			})
+
+			// Recursively collect nested options of every selected case
+			for _, selectedCase := range optDef.SelectedCases(selectedValue) {
+				collectExpectedOptions(expected, selectedCase.Option, currentValues, optionDefs, scope)
 			}
+
+		case "input":
+			// For input type, fill missing inputs with defaults and convert the rest, hidden inputs are dropped
+			*expected = append(*expected, ConfigTaskOption{
+				Name:  optName,
+				Value: normalizeInputValues(optName, visibleInputs(optDef, scope, optionDefs), currentValues[optName]),
+			})
 		}
 	}
 }
 
-// saveConfig saves config to file
+// saveConfig saves the current config to file. The config is marshaled under configMu
+// and saves are serialized, so the file always ends up with the newest revision.
 func (s *service) saveConfig() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.configMu.RLock()
 	if s.config == nil {
+		s.configMu.RUnlock()
 		return fmt.Errorf("config is nil")
 	}
+	path, revision := s.configPath, s.revision
+	data, err := marshalConfig(s.config)
+	s.configMu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	// a concurrent save already wrote this revision
+	if path == s.savedPath && revision == s.savedRevision {
+		return nil
+	}
+	if err := writeConfigData(path, data); err != nil {
+		return err
+	}
+	s.savedPath, s.savedRevision = path, revision
+
+	events.Publish(events.ConfigSaved{Path: path})
+	return nil
+}
+
+// marshalConfig encodes a config for its file with the current version, config is not changed
+func marshalConfig(config *InterfaceConfig) ([]byte, error) {
+	if config.ConfigVersion < ConfigVersion {
+		versioned := *config
+		versioned.ConfigVersion = ConfigVersion
+		config = &versioned
+	}
 
-	data, err := json.MarshalIndent(s.config, "", "  ")
+	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
-		return fmt.Errorf("marshal config failed: %w", err)
+		return nil, fmt.Errorf("marshal config failed: %w", err)
 	}
+	return data, nil
+}
 
-	if err := os.WriteFile(s.configPath, data, 0644); err != nil {
-		return fmt.Errorf("write config file failed: %w", err)
+// writeConfig writes a config file
+func writeConfig(path string, config *InterfaceConfig) error {
+	data, err := marshalConfig(config)
+	if err != nil {
+		return err
+	}
+	return writeConfigData(path, data)
+}
+
+// writeConfigData writes an encoded config file, the previous one is kept as a backup
+func writeConfigData(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create config directory failed: %w", err)
 	}
 
+	if err := safefile.Backup(path, safefile.MaxBackups); err != nil {
+		log.Printf("backup config %s failed: %v", path, err)
+	}
+	if err := safefile.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write config file failed: %w", err)
+	}
 	return nil
 }
 
+// validConfig checks that data can be loaded as a config
+func validConfig(data []byte) error {
+	migrated, _, err := configMigrations.Migrate(data)
+	if err != nil {
+		return err
+	}
+	var config InterfaceConfig
+	return json.Unmarshal(migrated, &config)
+}
+
+// recoverConfig restores the config file from the newest valid backup after it failed to load.
+// The unreadable file is moved aside. Returns false when the config has to be reset to defaults.
+func (s *service) recoverConfig() bool {
+	if _, err := os.Stat(s.configPath); err != nil {
+		// a missing file is a new project, nothing was lost
+		return false
+	}
+
+	recovered := events.ConfigRecovered{Path: s.configPath}
+	corrupt, err := safefile.Quarantine(s.configPath)
+	if err != nil {
+		log.Printf("move unreadable config aside failed: %v", err)
+	}
+	recovered.Corrupt = corrupt
+
+	defer func() {
+		events.Publish(recovered)
+		if recovered.Backup != "" {
+			events.Publish(events.Warn(fmt.Sprintf("config could not be read, restored from backup %s", filepath.Base(recovered.Backup))))
+		} else {
+			events.Publish(events.Warn("config could not be read, reset to defaults"))
+		}
+	}()
+
+	data, backup, err := safefile.Recover(s.configPath, validConfig)
+	if err != nil {
+		log.Printf("recover config failed: %v", err)
+		return false
+	}
+	if err := safefile.WriteFile(s.configPath, data, 0644); err != nil {
+		log.Printf("restore config from %s failed: %v", backup, err)
+		return false
+	}
+	if err := s.loadConfig(); err != nil {
+		log.Printf("load restored config failed: %v", err)
+		return false
+	}
+
+	log.Printf("config restored from %s", backup)
+	recovered.Backup = backup
+	return true
+}
+
 // GetConfig gets the full config
 func (s *service) GetConfig() *InterfaceConfig {
 	s.configMu.RLock()
 	defer s.configMu.RUnlock()
 
-	if s.config == nil {
-		return &InterfaceConfig{
-			Controller: ConfigController{},
-			Task:       []ConfigTask{},
-		}
-	}
-	return s.config
+	return s.currentConfig()
 }
 
-// SaveConfig saves the full config
-func (s *service) SaveConfig(config *InterfaceConfig) error {
-	s.configMu.Lock()
-	s.config = config
-	s.configMu.Unlock()
-
-	return s.saveConfig()
+// SaveConfig replaces the full config if revision is the current one and returns the new revision
+func (s *service) SaveConfig(revision int64, config *InterfaceConfig) (int64, error) {
+	return s.mutate(revision, "replace", func(current *InterfaceConfig, iface *V2Interface) error {
+		replacement := cloneConfig(config)
+		// the frontend drops the fields it does not know
+		if replacement.Extra == nil {
+			replacement.Extra = current.Extra
+		}
+		*current = *replacement
+		return nil
+	})
 }
 
 // ReadContent reads content from a file path, URL, or returns direct text
@@ -484,8 +646,8 @@ func (s *service) ReadContent(content string) string {
 	}
 
 	// Check if it's a file path (relative to base path)
-	if s.v2Loaded != nil && s.v2Loaded.BasePath != "" {
-		filePath := filepath.Join(s.v2Loaded.BasePath, content)
+	if v2Loaded := s.V2Loaded(); v2Loaded != nil && v2Loaded.BasePath != "" {
+		filePath := filepath.Join(v2Loaded.BasePath, content)
 		if data, err := os.ReadFile(filePath); err == nil {
 			return string(data)
 		}
@@ -495,8 +657,15 @@ func (s *service) ReadContent(content string) string {
 	return content
 }
 
-// readFromURL reads content from a URL
+// readFromURL reads content from a URL, successful reads are cached until the interface is reloaded
 func (s *service) readFromURL(url string) string {
+	s.contentMu.Lock()
+	cached, ok := s.contentCache[url]
+	s.contentMu.Unlock()
+	if ok {
+		return cached
+	}
+
 	client := &http.Client{
 		Timeout: 10 * time.Second,
 	}
@@ -519,5 +688,11 @@ func (s *service) readFromURL(url string) string {
 		return url
 	}
 
+	s.contentMu.Lock()
+	if s.contentCache == nil {
+		s.contentCache = make(map[string]string)
+	}
+	s.contentCache[url] = string(data)
+	s.contentMu.Unlock()
 	return string(data)
 }
@@ -0,0 +1,344 @@
+package pi
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ShareFormat is the version of exported configs
+const ShareFormat = 1
+
+// shareCodePrefix marks a share code, the rest is the deflated export in unpadded base64url
+const shareCodePrefix = "MUU1."
+
+// SharedConfig is an exported profile
+type SharedConfig struct {
+	Format  int             `json:"format"`
+	Project string          `json:"project"` // name of the interface the config was made for
+	Config  InterfaceConfig `json:"config"`
+}
+
+// ImportResult reports how an imported config was fitted to the current interface
+type ImportResult struct {
+	Profile string  `json:"profile"`
+	Issues  []Issue `json:"issues"` // dropped or unknown entries, all warnings
+}
+
+// EncodeShareCode compresses an exported config into a share code
+func EncodeShareCode(data []byte) (string, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return shareCodePrefix + base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeShareCode returns the exported config of a share code, whitespace added by chat clients is ignored
+func DecodeShareCode(code string) ([]byte, error) {
+	code = strings.Join(strings.Fields(code), "")
+	encoded, ok := strings.CutPrefix(code, shareCodePrefix)
+	if !ok {
+		return nil, errors.New("not a share code")
+	}
+	compressed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode share code failed: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(compressed)), 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("decode share code failed: %w", err)
+	}
+	return data, nil
+}
+
+// exportProfile encodes a profile, device-specific fields are only kept with includeDevice
+func (s *service) exportProfile(name string, includeDevice bool) ([]byte, error) {
+	config, err := s.LoadProfile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	shared := SharedConfig{
+		Format: ShareFormat,
+		Config: *config,
+	}
+	if v2Loaded := s.V2Loaded(); v2Loaded != nil && v2Loaded.Interface != nil {
+		shared.Project = v2Loaded.Interface.Name
+	}
+	if !includeDevice {
+		shared.Config.Adb = nil
+		shared.Config.Win32 = nil
+	}
+
+	data, err := json.MarshalIndent(shared, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal config failed: %w", err)
+	}
+	return data, nil
+}
+
+// migrateSharedConfig migrates the config of an export made by an older version
+func migrateSharedConfig(raw []byte, shared *SharedConfig) error {
+	var export struct {
+		Config json.RawMessage `json:"config"`
+	}
+	if err := json.Unmarshal(raw, &export); err != nil {
+		return err
+	}
+	migrated, _, err := configMigrations.Migrate(export.Config)
+	if err != nil {
+		return err
+	}
+	shared.Config = InterfaceConfig{}
+	return json.Unmarshal(migrated, &shared.Config)
+}
+
+// fitConfig fits an imported config to the loaded interface with syncConfig and reports what it changed.
+// Tasks the interface does not know are dropped instead of kept.
+func (s *service) fitConfig(config *InterfaceConfig) []Issue {
+	issues := []Issue{}
+	warn := func(path string, format string, args ...interface{}) {
+		issues = append(issues, Issue{Path: path, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// imported tasks get new ids, the report refers to the tasks by them
+	paths := make(map[string]string, len(config.Task))
+	before := make(map[string][]string, len(config.Task))
+	for i := range config.Task {
+		task := &config.Task[i]
+		task.ID = uuid.New().String()
+		paths[task.ID] = fmt.Sprintf("task[%d]", i)
+		for _, opt := range task.Option {
+			before[task.ID] = append(before[task.ID], opt.Name)
+		}
+	}
+
+	report, _ := s.syncConfig(config)
+	iface := s.V2Loaded().Interface
+
+	if report.Controller != nil {
+		warn("controller", "unknown controller %s, using %s", report.Controller.From, report.Controller.To)
+	}
+	if report.Resource != nil {
+		warn("resource", "unknown resource %s, using %s", report.Resource.From, report.Resource.To)
+	}
+	orphaned := make(map[string]bool, len(report.Orphaned))
+	for _, task := range report.Orphaned {
+		orphaned[task.ID] = true
+		warn(paths[task.ID], "unknown task %s dropped", task.Name)
+	}
+	for _, rename := range report.Renamed {
+		warn(paths[rename.ID], "task %s renamed to %s", rename.From, rename.To)
+	}
+	for _, reset := range report.Cases {
+		warn(paths[reset.Task.ID]+".option."+reset.Option, "unknown case %s reset", reset.Case)
+	}
+	for _, reset := range report.Reset {
+		warn(paths[reset.Task.ID]+".option."+reset.Option, "invalid value of input %s reset to default: %s", reset.Input, reset.Reason)
+	}
+
+	tasks := []ConfigTask{}
+	for _, task := range config.Task {
+		if orphaned[task.ID] {
+			continue
+		}
+		kept := make(map[string]bool, len(task.Option))
+		for _, opt := range task.Option {
+			kept[opt.Name] = true
+		}
+		for _, name := range before[task.ID] {
+			// inputs stored separately by config version 1 are grouped into their option
+			if optName, _, grouped := splitInputName(name, iface.Option); grouped {
+				name = optName
+			}
+			if !kept[name] {
+				warn(paths[task.ID]+".option."+name, "option not used by task %s dropped", task.Name)
+			}
+		}
+		tasks = append(tasks, task)
+	}
+	for _, task := range report.Added {
+		warn("task", "new task %s added", task.Name)
+	}
+	config.Task = tasks
+
+	return issues
+}
+
+func findController(iface *V2Interface, name string) *V2Controller {
+	for i := range iface.Controller {
+		if iface.Controller[i].Name == name {
+			return &iface.Controller[i]
+		}
+	}
+	return nil
+}
+
+func findResource(iface *V2Interface, name string) *V2Resource {
+	for i := range iface.Resource {
+		if iface.Resource[i].Name == name {
+			return &iface.Resource[i]
+		}
+	}
+	return nil
+}
+
+func findTask(iface *V2Interface, name string) *V2Task {
+	for i := range iface.Task {
+		if iface.Task[i].Name == name {
+			return &iface.Task[i]
+		}
+	}
+	return nil
+}
+
+func findCase(opt *V2Option, name string) *V2OptionCase {
+	for i := range opt.Cases {
+		if opt.Cases[i].Name == name {
+			return &opt.Cases[i]
+		}
+	}
+	return nil
+}
+
+// ==================== frontend exposed interfaces ====================
+
+// ExportProfile exports a profile as JSON, adb and win32 settings are only included with includeDevice
+func (s *service) ExportProfile(name string, includeDevice bool) (string, error) {
+	data, err := s.exportProfile(name, includeDevice)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ExportShareCode exports a profile as a share code to paste in chat
+func (s *service) ExportShareCode(name string, includeDevice bool) (string, error) {
+	data, err := s.exportProfile(name, includeDevice)
+	if err != nil {
+		return "", err
+	}
+	return EncodeShareCode(data)
+}
+
+// ExportProfileFile asks for a file and exports a profile to it.
+// Returns the chosen path, or an empty string when the dialog was cancelled.
+func (s *service) ExportProfileFile(name string, includeDevice bool) (string, error) {
+	if s.ctx == nil {
+		return "", errors.New("no window to show the dialog in")
+	}
+	data, err := s.exportProfile(name, includeDevice)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := runtime.SaveFileDialog(s.ctx, runtime.SaveDialogOptions{
+		Title:           "Export profile",
+		DefaultFilename: name + ".json",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Config (*.json)", Pattern: "*.json"},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("write export failed: %w", err)
+	}
+	return path, nil
+}
+
+// ImportProfile imports an exported config or a share code as a new profile
+func (s *service) ImportProfile(data string, name string) (*ImportResult, error) {
+	raw := []byte(data)
+	if strings.HasPrefix(strings.TrimSpace(data), shareCodePrefix) {
+		decoded, err := DecodeShareCode(data)
+		if err != nil {
+			return nil, err
+		}
+		raw = decoded
+	}
+
+	var shared SharedConfig
+	if err := json.Unmarshal(raw, &shared); err != nil {
+		return nil, fmt.Errorf("parse imported config failed: %w", err)
+	}
+	if shared.Format != ShareFormat {
+		return nil, fmt.Errorf("unsupported config format: %d", shared.Format)
+	}
+	if shared.Config.ConfigVersion < ConfigVersion {
+		// exported by an older version
+		if err := migrateSharedConfig(raw, &shared); err != nil {
+			return nil, fmt.Errorf("parse imported config failed: %w", err)
+		}
+	}
+
+	v2Loaded := s.V2Loaded()
+	if v2Loaded == nil || v2Loaded.Interface == nil {
+		return nil, errors.New("interface not loaded")
+	}
+	path, err := s.newProfilePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &shared.Config
+	if config.Task == nil {
+		config.Task = []ConfigTask{}
+	}
+	issues := []Issue{}
+	if shared.Project != "" && shared.Project != v2Loaded.Interface.Name {
+		issues = append(issues, Issue{
+			Path:     "project",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("config was made for %s", shared.Project),
+		})
+	}
+	issues = append(issues, s.fitConfig(config)...)
+
+	if err := writeConfig(path, config); err != nil {
+		return nil, err
+	}
+	return &ImportResult{Profile: name, Issues: issues}, nil
+}
+
+// ImportProfileFile asks for an exported config and imports it as a new profile.
+// Returns nil when the dialog was cancelled.
+func (s *service) ImportProfileFile(name string) (*ImportResult, error) {
+	if s.ctx == nil {
+		return nil, errors.New("no window to show the dialog in")
+	}
+
+	path, err := runtime.OpenFileDialog(s.ctx, runtime.OpenDialogOptions{
+		Title: "Import profile",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Config (*.json)", Pattern: "*.json"},
+		},
+	})
+	if err != nil || path == "" {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read import failed: %w", err)
+	}
+	return s.ImportProfile(string(data), name)
+}
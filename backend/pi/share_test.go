@@ -0,0 +1,124 @@
+package pi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareCode(t *testing.T) {
+	data := []byte(`{"format": 1, "config": {"task": []}}`)
+	code, err := EncodeShareCode(data)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(code, shareCodePrefix))
+
+	// chat clients may wrap long codes
+	wrapped := code[:8] + "\n " + code[8:]
+	decoded, err := DecodeShareCode(wrapped)
+	require.NoError(t, err)
+	require.Equal(t, data, decoded)
+
+	_, err = DecodeShareCode("hello")
+	require.Error(t, err)
+}
+
+func TestImportProfile(t *testing.T) {
+	s, _ := openTestProject(t, `{
+		"interface_version": 2,
+		"name": "Share",
+		"controller": [{"name": "Emulator", "type": "Adb"}],
+		"resource": [{"name": "Official", "path": ["./resource"]}],
+		"task": [{"name": "A", "entry": "A", "default_check": true, "aliases": ["Old"], "option": ["Mode"]}],
+		"option": {"Mode": {"cases": [{"name": "Fast"}, {"name": "Slow"}]}}
+	}`)
+
+	revision, err := s.SetTaskOption(s.GetRevision(), s.GetConfig().Task[0].ID, "Mode", "Slow")
+	require.NoError(t, err)
+	require.Equal(t, revision, s.GetRevision())
+	config := cloneConfig(s.GetConfig())
+	config.Adb = &ConfigAdb{AdbPath: "/usr/bin/adb", Address: "127.0.0.1:5555"}
+	_, err = s.SaveConfig(revision, config)
+	require.NoError(t, err)
+
+	t.Run("round trip without device fields", func(t *testing.T) {
+		code, err := s.ExportShareCode(DefaultProfile, false)
+		require.NoError(t, err)
+
+		result, err := s.ImportProfile(code, "shared")
+		require.NoError(t, err)
+		require.Empty(t, result.Issues)
+
+		imported, err := s.LoadProfile("shared")
+		require.NoError(t, err)
+		require.Nil(t, imported.Adb)
+		require.Equal(t, "Slow", imported.Task[0].Option[0].Value)
+		require.NotEqual(t, config.Task[0].ID, imported.Task[0].ID)
+	})
+
+	t.Run("device fields are kept on request", func(t *testing.T) {
+		data, err := s.ExportProfile(DefaultProfile, true)
+		require.NoError(t, err)
+
+		_, err = s.ImportProfile(data, "device")
+		require.NoError(t, err)
+		imported, err := s.LoadProfile("device")
+		require.NoError(t, err)
+		require.Equal(t, "127.0.0.1:5555", imported.Adb.Address)
+	})
+
+	t.Run("unknown entries are reported", func(t *testing.T) {
+		data := `{
+			"format": 1,
+			"project": "Other",
+			"config": {
+				"controller": {"name": "Desktop", "type": "Win32"},
+				"resource": "Official",
+				"task": [
+					{"id": "1", "name": "Removed", "checked": true},
+					{"id": "2", "name": "A", "checked": true, "option": [
+						{"name": "Mode", "value": "Turbo"},
+						{"name": "Extra", "value": "x"}
+					]}
+				]
+			}
+		}`
+		result, err := s.ImportProfile(data, "other")
+		require.NoError(t, err)
+
+		paths := []string{}
+		for _, issue := range result.Issues {
+			require.Equal(t, SeverityWarning, issue.Severity)
+			paths = append(paths, issue.Path)
+		}
+		require.Equal(t, []string{"project", "controller", "task[0]", "task[1].option.Mode", "task[1].option.Extra"}, paths)
+
+		imported, err := s.LoadProfile("other")
+		require.NoError(t, err)
+		require.Equal(t, "Emulator", imported.Controller.Name)
+		require.Equal(t, 1, len(imported.Task))
+		require.Equal(t, []ConfigTaskOption{{Name: "Mode", Value: "Fast"}}, imported.Task[0].Option)
+	})
+
+	t.Run("renamed tasks follow the aliases", func(t *testing.T) {
+		data := `{"format": 1, "config": {"controller": {"name": "Emulator", "type": "Adb"}, "resource": "Official", "task": [
+			{"id": "1", "name": "Old", "checked": true, "option": [{"name": "Mode", "value": "Slow"}]}
+		]}}`
+		result, err := s.ImportProfile(data, "renamed")
+		require.NoError(t, err)
+		require.Len(t, result.Issues, 1)
+		require.Equal(t, "task[0]", result.Issues[0].Path)
+
+		imported, err := s.LoadProfile("renamed")
+		require.NoError(t, err)
+		require.Equal(t, []string{"A"}, taskNames(imported))
+		require.Equal(t, []ConfigTaskOption{{Name: "Mode", Value: "Slow"}}, imported.Task[0].Option)
+	})
+
+	t.Run("existing profiles are not overwritten", func(t *testing.T) {
+		data, err := s.ExportProfile(DefaultProfile, false)
+		require.NoError(t, err)
+		_, err = s.ImportProfile(data, DefaultProfile)
+		require.Error(t, err)
+	})
+}
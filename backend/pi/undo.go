@@ -0,0 +1,177 @@
+package pi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"muu-alpha/backend/events"
+	"muu-alpha/backend/safefile"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MaxUndo is the number of changes that can be undone
+const MaxUndo = 50
+
+// HistoryEntry is a change that can be undone or redone
+type HistoryEntry struct {
+	Change string    `json:"change"` // e.g. "move-task", "replace"
+	Time   time.Time `json:"time"`
+}
+
+// ConfigHistory lists the changes that can be undone and redone, newest first
+type ConfigHistory struct {
+	Undo []HistoryEntry `json:"undo"`
+	Redo []HistoryEntry `json:"redo"`
+}
+
+// undoEntry is the config as it was before a change
+type undoEntry struct {
+	Change string           `json:"change"`
+	Time   time.Time        `json:"time"`
+	Config *InterfaceConfig `json:"config"`
+}
+
+// undoHistory is the undo and redo stack of a config file, the top is the last entry
+type undoHistory struct {
+	Version int         `json:"version"` // config version of the recorded configs
+	Undo    []undoEntry `json:"undo"`
+	Redo    []undoEntry `json:"redo"`
+
+	path     string           // where the history is persisted
+	baseline *InterfaceConfig // copy of the config after the last recorded change
+}
+
+// undoPath returns where the history of the config at configPath is persisted
+func undoPath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "undo", filepath.Base(configPath))
+}
+
+// resetUndo loads the history of the current config file, configMu must be held.
+// A missing or unreadable history starts empty.
+func (s *service) resetUndo() {
+	h := &undoHistory{path: undoPath(s.configPath)}
+	if data, err := os.ReadFile(h.path); err == nil {
+		if err := json.Unmarshal(data, h); err != nil {
+			log.Printf("read undo history %s failed: %v", h.path, err)
+			h.Undo, h.Redo = nil, nil
+		}
+	}
+	// configs recorded before a migration cannot be restored
+	if h.Version < ConfigVersion && len(h.Undo)+len(h.Redo) > 0 {
+		log.Printf("undo history %s has config version %d, older than %d, dropped", h.path, h.Version, ConfigVersion)
+		h.Undo, h.Redo = nil, nil
+	}
+	h.baseline = cloneConfig(s.currentConfig())
+	s.undo = h
+}
+
+// undoHistoryLocked returns the history of the current config file, configMu must be held
+func (s *service) undoHistoryLocked() *undoHistory {
+	if s.undo == nil || s.undo.path != undoPath(s.configPath) {
+		s.resetUndo()
+	}
+	return s.undo
+}
+
+// recordChange pushes the config before a change on the undo stack and clears the redo stack.
+// Changes that leave the config as it was are not recorded. configMu must be held.
+func (s *service) recordChange(change string, config *InterfaceConfig) {
+	h := s.undoHistoryLocked()
+	if sameJSON(h.baseline, config) {
+		return
+	}
+
+	h.Undo = append(h.Undo, undoEntry{Change: change, Time: time.Now(), Config: h.baseline})
+	if len(h.Undo) > MaxUndo {
+		h.Undo = h.Undo[len(h.Undo)-MaxUndo:]
+	}
+	h.Redo = nil
+	h.baseline = cloneConfig(config)
+	h.save()
+}
+
+// save persists the history, failures only lose the history and are logged
+func (h *undoHistory) save() {
+	h.Version = ConfigVersion
+	data, err := json.Marshal(h)
+	if err != nil {
+		log.Printf("marshal undo history failed: %v", err)
+		return
+	}
+	if err := safefile.WriteFile(h.path, data, 0644); err != nil {
+		log.Printf("write undo history failed: %v", err)
+	}
+}
+
+// step restores the top config of the undo stack, or of the redo stack with redo,
+// and pushes the current config on the other one
+func (s *service) step(redo bool) (int64, error) {
+	s.configMu.Lock()
+	h := s.undoHistoryLocked()
+	change, from, to := "undo", &h.Undo, &h.Redo
+	if redo {
+		change, from, to = "redo", &h.Redo, &h.Undo
+	}
+	if len(*from) == 0 {
+		s.configMu.Unlock()
+		return 0, fmt.Errorf("nothing to %s", change)
+	}
+
+	entry := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, undoEntry{Change: entry.Change, Time: time.Now(), Config: h.baseline})
+
+	// the interface may have changed since the entry was recorded
+	config := cloneConfig(entry.Config)
+	s.syncConfig(config)
+	s.config = config
+	s.revision++
+	h.baseline = cloneConfig(config)
+	h.save()
+	changed := events.ConfigChanged{Revision: s.revision, Profile: s.profile, Change: change}
+	s.configMu.Unlock()
+
+	if err := s.saveConfig(); err != nil {
+		return changed.Revision, err
+	}
+	events.Publish(changed)
+	return changed.Revision, nil
+}
+
+// moveUndoHistory follows a renamed profile, a missing history is not an error
+func moveUndoHistory(configPath string, newConfigPath string) {
+	if err := os.Rename(undoPath(configPath), undoPath(newConfigPath)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("move undo history failed: %v", err)
+	}
+}
+
+// ==================== frontend exposed interfaces ====================
+
+// Undo restores the config as it was before the last change and returns the new revision
+func (s *service) Undo() (int64, error) {
+	return s.step(false)
+}
+
+// Redo applies the last undone change again and returns the new revision
+func (s *service) Redo() (int64, error) {
+	return s.step(true)
+}
+
+// GetHistory gets the changes of the active profile that can be undone and redone, newest first
+func (s *service) GetHistory() ConfigHistory {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	h := s.undoHistoryLocked()
+	entries := func(stack []undoEntry) []HistoryEntry {
+		list := make([]HistoryEntry, 0, len(stack))
+		for i := len(stack) - 1; i >= 0; i-- {
+			list = append(list, HistoryEntry{Change: stack[i].Change, Time: stack[i].Time})
+		}
+		return list
+	}
+	return ConfigHistory{Undo: entries(h.Undo), Redo: entries(h.Redo)}
+}
@@ -0,0 +1,79 @@
+package pi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUndoRedo(t *testing.T) {
+	s, ifacePath := openTestProject(t, mutationInterface)
+
+	_, err := s.Undo()
+	require.Error(t, err)
+
+	first := s.GetConfig().Task[0].ID
+	revision, err := s.MoveTask(s.GetRevision(), first, -1)
+	require.NoError(t, err)
+	revision, err = s.SetController(revision, "Desktop")
+	require.NoError(t, err)
+	// saving the same config again is not a change
+	revision, err = s.SaveConfig(revision, cloneConfig(s.GetConfig()))
+	require.NoError(t, err)
+
+	history := s.GetHistory()
+	require.Equal(t, []string{"set-controller", "move-task"}, historyChanges(history.Undo))
+	require.Empty(t, history.Redo)
+
+	revision, err = s.Undo()
+	require.NoError(t, err)
+	require.Equal(t, s.GetRevision(), revision)
+	require.Equal(t, "Phone", s.GetConfig().Controller.Name)
+	require.Equal(t, first, s.GetConfig().Task[1].ID)
+
+	_, err = s.Undo()
+	require.NoError(t, err)
+	require.Equal(t, first, s.GetConfig().Task[0].ID)
+
+	_, err = s.Redo()
+	require.NoError(t, err)
+	require.Equal(t, first, s.GetConfig().Task[1].ID)
+
+	history = s.GetHistory()
+	require.Equal(t, []string{"move-task"}, historyChanges(history.Undo))
+	require.Equal(t, []string{"set-controller"}, historyChanges(history.Redo))
+
+	t.Run("persisted", func(t *testing.T) {
+		require.NoError(t, s.OpenProject(ifacePath))
+		require.Equal(t, historyChanges(history.Undo), historyChanges(s.GetHistory().Undo))
+		require.Equal(t, historyChanges(history.Redo), historyChanges(s.GetHistory().Redo))
+
+		_, err := s.Redo()
+		require.NoError(t, err)
+		require.Equal(t, "Desktop", s.GetConfig().Controller.Name)
+	})
+
+	t.Run("a new change clears redo", func(t *testing.T) {
+		_, err := s.Undo()
+		require.NoError(t, err)
+		_, err = s.SetResource(s.GetRevision(), "Global")
+		require.NoError(t, err)
+		require.Empty(t, s.GetHistory().Redo)
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		for i := 0; i < MaxUndo+5; i++ {
+			_, err := s.SetTaskChecked(s.GetRevision(), first, i%2 == 0)
+			require.NoError(t, err)
+		}
+		require.Len(t, s.GetHistory().Undo, MaxUndo)
+	})
+}
+
+func historyChanges(entries []HistoryEntry) []string {
+	changes := []string{}
+	for _, entry := range entries {
+		changes = append(changes, entry.Change)
+	}
+	return changes
+}
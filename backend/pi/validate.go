@@ -0,0 +1,464 @@
+package pi
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Severity is the severity of a validation issue
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // the interface cannot be loaded
+	SeverityWarning Severity = "warning" // the interface loads but is likely wrong
+)
+
+// Issue is a single problem found in an interface
+type Issue struct {
+	File     string   `json:"file,omitempty"` // imported file the path is in, empty for the interface file
+	Path     string   `json:"path"`           // JSON path, e.g. "option.Foo.cases[2].option[0]"
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// String returns the issue in "severity: path: message" form, prefixed by the file of imported issues
+func (i Issue) String() string {
+	s := i.Message
+	if i.Path != "" {
+		s = i.Path + ": " + s
+	}
+	if i.File != "" {
+		s = i.File + ": " + s
+	}
+	return fmt.Sprintf("%s: %s", i.Severity, s)
+}
+
+// Report is the result of validating an interface
+type Report struct {
+	Issues []Issue `json:"issues"`
+}
+
+// HasErrors reports whether the report contains any error
+func (r *Report) HasErrors() bool {
+	return len(r.Errors()) > 0
+}
+
+// Errors returns the issues with error severity
+func (r *Report) Errors() []Issue {
+	return r.filter(SeverityError)
+}
+
+// Warnings returns the issues with warning severity
+func (r *Report) Warnings() []Issue {
+	return r.filter(SeverityWarning)
+}
+
+func (r *Report) filter(severity Severity) []Issue {
+	issues := []Issue{}
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// Err returns a *ValidationError holding every error, or nil if there is none
+func (r *Report) Err() error {
+	errs := r.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Issues: errs}
+}
+
+// ValidationError is returned when an interface has validation errors
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		msg := issue.Message
+		if issue.Path != "" {
+			msg = issue.Path + ": " + msg
+		}
+		if issue.File != "" {
+			msg = issue.File + ": " + msg
+		}
+		msgs = append(msgs, msg)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// validator collects issues while walking an interface
+type validator struct {
+	iface  *V2Interface
+	report *Report
+}
+
+func (v *validator) errorf(path string, format string, args ...interface{}) {
+	v.add(path, SeverityError, format, args...)
+}
+
+func (v *validator) warnf(path string, format string, args ...interface{}) {
+	v.add(path, SeverityWarning, format, args...)
+}
+
+func (v *validator) add(path string, severity Severity, format string, args ...interface{}) {
+	v.report.Issues = append(v.report.Issues, Issue{
+		Path:     path,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// ValidateV2 validates the V2Interface and reports every issue found
+func ValidateV2(iface *V2Interface) *Report {
+	v := &validator{
+		iface:  iface,
+		report: &Report{Issues: []Issue{}},
+	}
+
+	if iface.InterfaceVersion != 2 {
+		v.errorf("interface_version", "version mismatch: expected 2, got %d", iface.InterfaceVersion)
+	}
+
+	if iface.Name == "" {
+		v.errorf("name", "missing required field: name")
+	}
+
+	controllerNames := v.validateControllers()
+	resourceNames := v.validateResources(controllerNames)
+	v.validateAgent()
+	v.validateTasks(resourceNames)
+	v.validateOptions(resourceNames)
+	v.validateOptionGraph()
+	v.validatePresets()
+
+	// issues in sections merged from imported files point into those files
+	for i := range v.report.Issues {
+		issue := &v.report.Issues[i]
+		issue.File, issue.Path = iface.locate(issue.Path)
+	}
+	v.report.Issues = append(append([]Issue{}, iface.importIssues...), v.report.Issues...)
+
+	return v.report
+}
+
+// validateV2 validates the V2Interface, returning all errors as a *ValidationError
+func validateV2(iface *V2Interface) error {
+	return ValidateV2(iface).Err()
+}
+
+func (v *validator) validateControllers() map[string]bool {
+	controllerNames := make(map[string]bool)
+	for i, ctrl := range v.iface.Controller {
+		path := fmt.Sprintf("controller[%d]", i)
+
+		if ctrl.Name == "" {
+			v.errorf(path+".name", "missing name")
+		} else if controllerNames[ctrl.Name] {
+			v.errorf(path+".name", "duplicate name: %s", ctrl.Name)
+		}
+		controllerNames[ctrl.Name] = true
+
+		switch ctrl.Type {
+		case "Adb":
+		case "Win32":
+			if ctrl.Win32 == nil {
+				v.warnf(path+".win32", "missing win32 config, no window can be matched")
+			} else {
+				if _, err := regexp.Compile(ctrl.Win32.ClassRegex); err != nil {
+					v.warnf(path+".win32.class_regex", "invalid regex: %v", err)
+				}
+				if _, err := regexp.Compile(ctrl.Win32.WindowRegex); err != nil {
+					v.warnf(path+".win32.window_regex", "invalid regex: %v", err)
+				}
+			}
+		default:
+			v.errorf(path+".type", "invalid type: %s", ctrl.Type)
+		}
+
+		// validate exclusive fields
+		count := 0
+		if ctrl.DisplayShortSide != nil {
+			count++
+		}
+		if ctrl.DisplayLongSide != nil {
+			count++
+		}
+		if ctrl.DisplayRaw {
+			count++
+		}
+		if count > 1 {
+			v.errorf(path, "display options are exclusive")
+		}
+	}
+	return controllerNames
+}
+
+func (v *validator) validateResources(controllerNames map[string]bool) map[string]bool {
+	resourceNames := make(map[string]bool)
+	for i, res := range v.iface.Resource {
+		path := fmt.Sprintf("resource[%d]", i)
+
+		if res.Name == "" {
+			v.errorf(path+".name", "missing name")
+		} else if resourceNames[res.Name] {
+			v.errorf(path+".name", "duplicate name: %s", res.Name)
+		}
+		resourceNames[res.Name] = true
+
+		if len(res.Path) == 0 {
+			v.errorf(path+".path", "missing path")
+		}
+
+		for j, ctrlName := range res.Controller {
+			if !controllerNames[ctrlName] {
+				v.errorf(fmt.Sprintf("%s.controller[%d]", path, j), "reference to non-existent controller: %s", ctrlName)
+			}
+		}
+	}
+	return resourceNames
+}
+
+func (v *validator) validateAgent() {
+	if v.iface.Agent != nil && v.iface.Agent.ChildExec == "" {
+		v.errorf("agent.child_exec", "missing child_exec")
+	}
+}
+
+func (v *validator) validateTasks(resourceNames map[string]bool) {
+	taskNames := make(map[string]bool)
+	for i, task := range v.iface.Task {
+		path := fmt.Sprintf("task[%d]", i)
+
+		if task.Name == "" {
+			v.errorf(path+".name", "missing name")
+		} else if taskNames[task.Name] {
+			v.warnf(path+".name", "duplicate name: %s, only the first task is used", task.Name)
+		}
+		taskNames[task.Name] = true
+
+		if task.Entry == "" {
+			v.errorf(path+".entry", "missing entry")
+		}
+
+		for j, resName := range task.Resource {
+			if !resourceNames[resName] {
+				v.errorf(fmt.Sprintf("%s.resource[%d]", path, j), "reference to non-existent resource: %s", resName)
+			}
+		}
+
+		for j, optName := range task.Option {
+			if _, ok := v.iface.Option[optName]; !ok {
+				v.errorf(fmt.Sprintf("%s.option[%d]", path, j), "reference to non-existent option: %s", optName)
+			}
+		}
+	}
+
+	// aliases are checked once every task name is known
+	aliasOwners := make(map[string]string)
+	for i, task := range v.iface.Task {
+		for j, alias := range task.Aliases {
+			path := fmt.Sprintf("task[%d].aliases[%d]", i, j)
+			switch owner, ok := aliasOwners[alias]; {
+			case taskNames[alias]:
+				v.errorf(path, "alias is the name of a task: %s", alias)
+			case ok && owner != task.Name:
+				v.errorf(path, "alias already used by task %s: %s", owner, alias)
+			case ok:
+				v.warnf(path, "duplicate alias: %s", alias)
+			}
+			aliasOwners[alias] = task.Name
+		}
+	}
+}
+
+func (v *validator) validateOptions(resourceNames map[string]bool) {
+	controllerTypes := make(map[string]bool)
+	for _, ctrl := range v.iface.Controller {
+		controllerTypes[ctrl.Type] = true
+	}
+
+	for _, name := range sortedKeys(v.iface.Option) {
+		opt := v.iface.Option[name]
+		path := "option." + name
+		optType := opt.GetType()
+
+		v.validateCondition(path+".when", opt.When, controllerTypes, resourceNames)
+		if _, ok := opt.When.option()[name]; ok {
+			v.errorf(path+".when.option."+name, "condition on the option itself, it is never shown")
+		}
+
+		switch optType {
+		case "select", "switch", "checkbox":
+			if len(opt.Cases) == 0 {
+				v.errorf(path+".cases", "missing cases")
+			}
+			if optType == "switch" && len(opt.Cases) != 2 {
+				v.errorf(path+".cases", "switch must have 2 cases")
+			}
+
+			caseNames := make(map[string]bool)
+			for j, c := range opt.Cases {
+				casePath := fmt.Sprintf("%s.cases[%d]", path, j)
+				if c.Name == "" {
+					v.errorf(casePath+".name", "missing name")
+				} else if caseNames[c.Name] {
+					v.errorf(casePath+".name", "duplicate case name: %s", c.Name)
+				}
+				caseNames[c.Name] = true
+
+				for k, subOpt := range c.Option {
+					if _, ok := v.iface.Option[subOpt]; !ok {
+						v.errorf(fmt.Sprintf("%s.option[%d]", casePath, k), "reference to non-existent option: %s", subOpt)
+					}
+				}
+			}
+
+			if optType == "checkbox" {
+				if opt.DefaultCase != "" {
+					v.warnf(path+".default_case", "default_case is ignored by checkbox, use default_cases")
+				}
+				for j, name := range opt.DefaultCases {
+					if !caseNames[name] {
+						v.errorf(fmt.Sprintf("%s.default_cases[%d]", path, j), "default case does not exist: %s", name)
+					}
+				}
+			} else {
+				if opt.DefaultCase != "" && !caseNames[opt.DefaultCase] {
+					v.errorf(path+".default_case", "default_case does not exist: %s", opt.DefaultCase)
+				}
+				if len(opt.DefaultCases) > 0 {
+					v.warnf(path+".default_cases", "default_cases is only used by checkbox")
+				}
+			}
+
+		case "input":
+			if len(opt.Inputs) == 0 {
+				v.errorf(path+".inputs", "missing inputs")
+			}
+
+			for j, input := range opt.Inputs {
+				inputPath := fmt.Sprintf("%s.inputs[%d]", path, j)
+				if input.Name == "" {
+					v.errorf(inputPath+".name", "missing name")
+				}
+				v.validateCondition(inputPath+".when", input.When, controllerTypes, resourceNames)
+				if input.Verify != "" {
+					if _, err := regexp.Compile(input.Verify); err != nil {
+						v.errorf(inputPath+".verify", "invalid regex: %v", err)
+					}
+				}
+				switch input.PipelineType {
+				case "", "string", "int", "bool":
+				default:
+					v.warnf(inputPath+".pipeline_type", "unknown pipeline_type %s, the value is passed as a string", input.PipelineType)
+				}
+				v.validateInputKind(inputPath, input)
+			}
+
+		default:
+			v.errorf(path+".type", "invalid type: %s", optType)
+		}
+	}
+}
+
+// LintFile validates the interface file at path, v1 files are checked after conversion.
+// Problems with the file content are returned in the report, the error is only set when the file cannot be read.
+func LintFile(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file failed: %w", err)
+	}
+	return lint(data, path), nil
+}
+
+// Lint validates interface data, v1 data is checked after conversion.
+// Imports are relative to the working directory.
+func Lint(data []byte) *Report {
+	return lint(data, "")
+}
+
+// lint validates the data of the interface file at path, empty when the data has no file
+func lint(data []byte, path string) *Report {
+	failed := func(err error) *Report {
+		return &Report{Issues: []Issue{{Severity: SeverityError, Message: err.Error()}}}
+	}
+
+	version, err := DetectVersion(data)
+	if err != nil {
+		return failed(err)
+	}
+
+	if version == Version1 {
+		v1, err := ParseV1(data)
+		if err != nil {
+			return failed(err)
+		}
+		iface := ConvertV1ToV2(v1)
+		if iface.Name == "" {
+			iface.Name = "unnamed"
+		}
+		return ValidateV2(iface)
+	}
+
+	// The schema pinpoints shape problems that make decoding fail
+	schemaIssues := validateSchema(InterfaceSchema(), data)
+
+	iface, err := decodeV2(data)
+	if err != nil {
+		report := &Report{Issues: schemaIssues}
+		if !report.HasErrors() {
+			report.Issues = append(report.Issues, Issue{Severity: SeverityError, Message: err.Error()})
+		}
+		return report
+	}
+	if err := resolveImports(iface, path); err != nil {
+		return failed(err)
+	}
+
+	report := ValidateV2(iface)
+
+	// Keep schema issues the semantic checks did not already report
+	reported := make(map[string]bool)
+	for _, issue := range report.Issues {
+		reported[issue.Path] = true
+	}
+	for _, issue := range schemaIssues {
+		if !reported[issue.Path] {
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+
+	return report
+}
+
+// sortedKeys returns the keys of m in sorted order, so reports are stable
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetValidationReport validates the interface file of the active project as it is on disk,
+// including edits that failed to reload
+func (s *service) GetValidationReport() (*Report, error) {
+	s.ifaceMu.RLock()
+	ifacePath := s.ifacePath
+	s.ifaceMu.RUnlock()
+
+	if ifacePath == "" {
+		return nil, fmt.Errorf("no interface loaded")
+	}
+	return LintFile(ifacePath)
+}
@@ -0,0 +1,103 @@
+package pi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateV2(t *testing.T) {
+	t.Run("collects every issue", func(t *testing.T) {
+		data := `{
+			"interface_version": 2,
+			"controller": [{"name": "Ctrl", "type": "Invalid"}],
+			"task": [{"name": "Task", "option": ["Foo", "Missing"]}],
+			"option": {
+				"Foo": {
+					"cases": [
+						{"name": "A"},
+						{"name": "B"},
+						{"name": "C", "option": ["Bar"]}
+					],
+					"default_case": "Z"
+				}
+			}
+		}`
+		iface, err := decodeV2([]byte(data))
+		require.NoError(t, err)
+
+		report := ValidateV2(iface)
+		paths := []string{}
+		for _, issue := range report.Errors() {
+			paths = append(paths, issue.Path)
+		}
+		require.Equal(t, []string{
+			"name",
+			"controller[0].type",
+			"task[0].entry",
+			"task[0].option[1]",
+			"option.Foo.cases[2].option[0]",
+			"option.Foo.default_case",
+		}, paths)
+	})
+
+	t.Run("warnings do not fail parsing", func(t *testing.T) {
+		data := `{
+			"interface_version": 2,
+			"name": "Test",
+			"controller": [{"name": "Desktop", "type": "Win32"}],
+			"task": [{"name": "Task", "entry": "Task", "option": ["Input"]}],
+			"option": {
+				"Input": {
+					"type": "input",
+					"inputs": [{"name": "Count", "pipeline_type": "float"}]
+				}
+			}
+		}`
+		iface, err := ParseV2([]byte(data))
+		require.NoError(t, err)
+
+		report := ValidateV2(iface)
+		require.False(t, report.HasErrors())
+		require.Equal(t, 2, len(report.Warnings()))
+	})
+
+	t.Run("parse error wraps every error", func(t *testing.T) {
+		_, err := ParseV2([]byte(`{"interface_version": 2, "task": [{"name": "Task"}]}`))
+
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		require.Equal(t, 2, len(validationErr.Issues))
+		require.Contains(t, err.Error(), "task[0].entry: missing entry")
+	})
+}
+
+func TestLint(t *testing.T) {
+	t.Run("invalid json", func(t *testing.T) {
+		report := Lint([]byte(`{invalid`))
+		require.True(t, report.HasErrors())
+	})
+
+	t.Run("v1 is checked after conversion", func(t *testing.T) {
+		report := Lint([]byte(`{"task": [{"name": "Task", "entry": "Entry", "option": ["Missing"]}]}`))
+		require.Equal(t, 1, len(report.Errors()))
+		require.Equal(t, "task[0].option[0]", report.Errors()[0].Path)
+	})
+
+	t.Run("schema mismatches", func(t *testing.T) {
+		report := Lint([]byte(`{
+			"interface_version": 2,
+			"name": "Test",
+			"$schema": "./interface.schema.json",
+			"controller": [{"name": "Ctrl", "type": "Adb", "display_raw": "yes"}],
+			"task": [{"name": "Task", "entry": "Task", "defualt_check": true}]
+		}`))
+		require.Equal(t, []Issue{
+			{Path: "controller[0].display_raw", Severity: SeverityError, Message: "expected boolean, got string"},
+		}, report.Errors())
+		require.Equal(t, []Issue{
+			{Path: "task[0].defualt_check", Severity: SeverityWarning, Message: "unknown property"},
+		}, report.Warnings())
+	})
+}
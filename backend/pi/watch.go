@@ -0,0 +1,168 @@
+package pi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"muu-alpha/backend/events"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// watchInterval is how often the interface and translation files are polled
+const watchInterval = time.Second
+
+// fileStamp identifies a version of a file on disk
+type fileStamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// statFiles stamps every file in paths
+func statFiles(paths []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			stamps[path] = fileStamp{}
+			continue
+		}
+		stamps[path] = fileStamp{
+			exists:  true,
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		}
+	}
+	return stamps
+}
+
+// sameStamps reports whether two sets of stamps are identical
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		other, ok := b[path]
+		if !ok || other.exists != stamp.exists || other.size != stamp.size || !other.modTime.Equal(stamp.modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// watchedFiles returns interface.json, the files it imports and every translation file of the loaded interface
+func (s *service) watchedFiles() []string {
+	s.ifaceMu.RLock()
+	defer s.ifaceMu.RUnlock()
+
+	if s.ifacePath == "" {
+		return nil
+	}
+
+	files := []string{s.ifacePath}
+	transFiles := []string{}
+	if s.v2Loaded != nil && s.v2Loaded.Interface != nil {
+		files = append(files, s.v2Loaded.Interface.imported...)
+		for _, transPath := range s.v2Loaded.Interface.Languages {
+			transFiles = append(transFiles, filepath.Join(s.v2Loaded.BasePath, transPath))
+		}
+	}
+	// Keep a stable order so lists can be compared between polls
+	sort.Strings(transFiles)
+
+	return append(files, transFiles...)
+}
+
+// watch polls the watched files and reloads the interface when they change, until ctx is done.
+// A reload happens once the files stop changing between two polls, so half-written files are skipped.
+func (s *service) watch(ctx context.Context) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	files := s.watchedFiles()
+	stamps := statFiles(files)
+	pending := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Another project was opened, start over with its files
+		if latest := s.watchedFiles(); !sameFiles(files, latest) {
+			files = latest
+			stamps = statFiles(files)
+			pending = false
+			continue
+		}
+
+		current := statFiles(files)
+		if !sameStamps(stamps, current) {
+			stamps = current
+			pending = true
+			continue
+		}
+		if !pending {
+			continue
+		}
+		pending = false
+
+		if err := s.Reload(); err != nil {
+			log.Printf("reload interface failed: %v", err)
+		}
+	}
+}
+
+// sameFiles reports whether two file lists are identical
+func sameFiles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Reload re-reads the interface and translation files from disk.
+// The config is synced to the new definitions. The previous interface is kept when loading fails.
+func (s *service) Reload() error {
+	s.ifaceMu.RLock()
+	ifacePath := s.ifacePath
+	old := s.v2Loaded
+	s.ifaceMu.RUnlock()
+
+	if ifacePath == "" {
+		return errors.New("no interface loaded")
+	}
+
+	version, v2Loaded, err := loadInterface(ifacePath)
+	if err != nil {
+		events.Publish(events.Warn(fmt.Sprintf("reload interface failed, keeping the previous one: %v", err)))
+		return err
+	}
+	s.setLoaded(version, v2Loaded, ifacePath)
+
+	reloaded := diffInterfaces(old, v2Loaded)
+	report, changed := s.syncConfigOptions()
+	reloaded.Sync = report
+	if changed {
+		reloaded.ConfigChanged = true
+		if err := s.saveConfig(); err != nil {
+			log.Printf("save synced config failed: %v", err)
+		}
+	}
+
+	log.Printf("interface reloaded: %+v", reloaded)
+	events.Publish(reloaded)
+	notifySync(report)
+	return nil
+}
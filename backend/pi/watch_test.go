@@ -0,0 +1,120 @@
+package pi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"muu-alpha/backend/events"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffInterfaces(t *testing.T) {
+	old := &V2Loaded{
+		Interface: &V2Interface{
+			Task: []V2Task{
+				{Name: "Keep", Entry: "Keep"},
+				{Name: "Change", Entry: "Before"},
+				{Name: "Remove", Entry: "Remove"},
+			},
+			Option: map[string]V2Option{
+				"Opt": {Cases: []V2OptionCase{{Name: "A"}}},
+			},
+		},
+		Resolvers: map[string]*V2I18nResolver{
+			"en": {translations: map[string]string{"k": "v"}},
+		},
+	}
+	new := &V2Loaded{
+		Interface: &V2Interface{
+			Task: []V2Task{
+				{Name: "Keep", Entry: "Keep"},
+				{Name: "Change", Entry: "After"},
+				{Name: "Add", Entry: "Add"},
+			},
+			Option: map[string]V2Option{
+				"Opt": {Cases: []V2OptionCase{{Name: "A"}}},
+			},
+		},
+		Resolvers: map[string]*V2I18nResolver{
+			"en": {translations: map[string]string{"k": "v2"}},
+		},
+	}
+
+	diff := diffInterfaces(old, new)
+	require.Equal(t, []string{"Add"}, diff.Tasks.Added)
+	require.Equal(t, []string{"Remove"}, diff.Tasks.Removed)
+	require.Equal(t, []string{"Change"}, diff.Tasks.Changed)
+	require.True(t, diff.Options.Empty())
+	require.Equal(t, []string{"en"}, diff.Languages.Changed)
+}
+
+func TestReload(t *testing.T) {
+	tmpDir := t.TempDir()
+	ifacePath := filepath.Join(tmpDir, "interface.json")
+	configPath := filepath.Join(tmpDir, "interface_config.json")
+
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(ifacePath, []byte(content), 0644))
+	}
+
+	write(`{
+		"interface_version": 2,
+		"name": "Test",
+		"task": [{"name": "A", "entry": "A", "option": ["Opt"]}],
+		"option": {"Opt": {"cases": [{"name": "X"}, {"name": "Y"}]}}
+	}`)
+	s := newTestService(t)
+	require.NoError(t, s.load(ifacePath, configPath))
+
+	var got []events.InterfaceReloaded
+	unsubscribe := events.Subscribe(func(e events.Event) {
+		if reloaded, ok := e.(events.InterfaceReloaded); ok {
+			got = append(got, reloaded)
+		}
+	})
+	defer unsubscribe()
+
+	t.Run("invalid file keeps the previous interface", func(t *testing.T) {
+		write(`{"interface_version": 2}`)
+		require.Error(t, s.Reload())
+		require.Equal(t, "Test", s.V2Loaded().Interface.Name)
+		require.Empty(t, got)
+	})
+
+	t.Run("valid file is applied and the config synced", func(t *testing.T) {
+		write(`{
+			"interface_version": 2,
+			"name": "Test",
+			"task": [{"name": "A", "entry": "A", "option": ["Opt", "Extra"]}],
+			"option": {
+				"Opt": {"cases": [{"name": "X"}, {"name": "Y"}]},
+				"Extra": {"cases": [{"name": "On"}]}
+			}
+		}`)
+		require.NoError(t, s.Reload())
+		require.Equal(t, 1, len(got))
+		require.Equal(t, []string{"Extra"}, got[0].Options.Added)
+		require.Equal(t, []string{"A"}, got[0].Tasks.Changed)
+		require.True(t, got[0].ConfigChanged)
+
+		names := []string{}
+		for _, opt := range s.GetConfig().Task[0].Option {
+			names = append(names, opt.Name)
+		}
+		require.ElementsMatch(t, []string{"Opt", "Extra"}, names)
+	})
+}
+
+func TestSameStamps(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "a.json")
+
+	before := statFiles([]string{path})
+	require.NoError(t, os.WriteFile(path, []byte("{}"), 0644))
+	after := statFiles([]string{path})
+
+	require.False(t, sameStamps(before, after))
+	require.True(t, sameStamps(after, statFiles([]string{path})))
+}
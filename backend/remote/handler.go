@@ -0,0 +1,330 @@
+package remote
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"muu-alpha/backend/engine"
+	"muu-alpha/backend/events"
+	"muu-alpha/backend/history"
+	"muu-alpha/backend/pi"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newHandler creates the routes of the remote API, all guarded by token
+func newHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/engine", handleEngineState)
+	mux.HandleFunc("POST /api/engine/start", handleEngineStart)
+	mux.HandleFunc("POST /api/engine/stop", handleEngineStop)
+	mux.HandleFunc("GET /api/config", handleGetConfig)
+	mux.HandleFunc("PUT /api/config", handleSaveConfig)
+	mux.HandleFunc("POST /api/config/tasks", handleAddTask)
+	mux.HandleFunc("DELETE /api/config/tasks/{id}", handleRemoveTask)
+	mux.HandleFunc("PUT /api/config/tasks/{id}/position", handleMoveTask)
+	mux.HandleFunc("PUT /api/config/tasks/{id}/checked", handleSetTaskChecked)
+	mux.HandleFunc("GET /api/config/tasks/{id}/options", handleGetVisibleOptions)
+	mux.HandleFunc("PUT /api/config/tasks/{id}/options/{name}", handleSetTaskOption)
+	mux.HandleFunc("PUT /api/config/controller", handleSetController)
+	mux.HandleFunc("PUT /api/config/resource", handleSetResource)
+	mux.HandleFunc("POST /api/config/preset", handleApplyPreset)
+	mux.HandleFunc("GET /api/history", handleGetHistory)
+	mux.HandleFunc("GET /api/history/{id}", handleGetRun)
+	mux.HandleFunc("GET /api/events", handleEvents)
+
+	return requireToken(token, mux)
+}
+
+// requireToken rejects requests without the token.
+// The token is read from the Authorization header ("Bearer <token>"),
+// or from the "token" query parameter on the event stream, for WebSocket clients that cannot set headers.
+// Other endpoints ignore the query parameter so the token does not end up in logs and browser history.
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := ""
+		if r.URL.Path == "/api/events" {
+			got = r.URL.Query().Get("token")
+		}
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			got = strings.TrimPrefix(auth, "Bearer ")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// EngineState is the response of GET /api/engine
+type EngineState struct {
+	Running    bool   `json:"running"`
+	MaaVersion string `json:"maa_version"`
+}
+
+func handleEngineState(w http.ResponseWriter, r *http.Request) {
+	eng := engine.Engine()
+	writeJSON(w, http.StatusOK, EngineState{
+		Running:    eng.GetIsRunning(),
+		MaaVersion: eng.GetMaaVersion(),
+	})
+}
+
+func handleEngineStart(w http.ResponseWriter, r *http.Request) {
+	eng := engine.Engine()
+	if eng.GetIsRunning() {
+		writeError(w, http.StatusConflict, engine.ErrAlreadyRunning.Error())
+		return
+	}
+	if profile := r.URL.Query().Get("profile"); profile != "" {
+		if err := eng.StartProfile(profile); err != nil {
+			status := http.StatusNotFound
+			if errors.Is(err, pi.ErrInvalidProfileName) {
+				status = http.StatusBadRequest
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+	} else {
+		eng.Start()
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func handleEngineStop(w http.ResponseWriter, r *http.Request) {
+	engine.Engine().Stop()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	p := pi.PI()
+	w.Header().Set("X-Config-Revision", strconv.FormatInt(p.GetRevision(), 10))
+	writeJSON(w, http.StatusOK, p.GetConfig())
+}
+
+func handleSaveConfig(w http.ResponseWriter, r *http.Request) {
+	revision, err := strconv.ParseInt(r.URL.Query().Get("revision"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid revision")
+		return
+	}
+	var config pi.InterfaceConfig
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid config: "+err.Error())
+		return
+	}
+	revision, err = pi.PI().SaveConfig(revision, &config)
+	writeRevision(w, revision, err)
+}
+
+// ConfigChange is the body of the granular config endpoints, Revision is the one the change is based on
+type ConfigChange struct {
+	Revision int64       `json:"revision"`
+	Name     string      `json:"name,omitempty"`
+	Index    *int        `json:"index,omitempty"` // missing appends the task
+	Checked  bool        `json:"checked,omitempty"`
+	Value    interface{} `json:"value,omitempty"` // case name, list of case names or object of input values
+}
+
+// index returns the requested task position, -1 for the end
+func (c ConfigChange) index() int {
+	if c.Index == nil {
+		return -1
+	}
+	return *c.Index
+}
+
+// RevisionResponse is the response of the granular config endpoints
+type RevisionResponse struct {
+	Revision int64 `json:"revision"`
+}
+
+// readChange decodes the body of a granular config endpoint
+func readChange(w http.ResponseWriter, r *http.Request) (ConfigChange, bool) {
+	var change ConfigChange
+	if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid change: "+err.Error())
+		return change, false
+	}
+	return change, true
+}
+
+// writeRevision answers a granular change, a change based on an outdated revision is a conflict
+func writeRevision(w http.ResponseWriter, revision int64, err error) {
+	switch {
+	case errors.Is(err, pi.ErrRevisionConflict):
+		w.Header().Set("X-Config-Revision", strconv.FormatInt(revision, 10))
+		writeError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, RevisionResponse{Revision: revision})
+	}
+}
+
+func handleAddTask(w http.ResponseWriter, r *http.Request) {
+	if change, ok := readChange(w, r); ok {
+		revision, err := pi.PI().AddTask(change.Revision, change.Name, change.index())
+		writeRevision(w, revision, err)
+	}
+}
+
+func handleRemoveTask(w http.ResponseWriter, r *http.Request) {
+	revision, err := strconv.ParseInt(r.URL.Query().Get("revision"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid revision")
+		return
+	}
+	revision, err = pi.PI().RemoveTask(revision, r.PathValue("id"))
+	writeRevision(w, revision, err)
+}
+
+func handleMoveTask(w http.ResponseWriter, r *http.Request) {
+	if change, ok := readChange(w, r); ok {
+		revision, err := pi.PI().MoveTask(change.Revision, r.PathValue("id"), change.index())
+		writeRevision(w, revision, err)
+	}
+}
+
+func handleSetTaskChecked(w http.ResponseWriter, r *http.Request) {
+	if change, ok := readChange(w, r); ok {
+		revision, err := pi.PI().SetTaskChecked(change.Revision, r.PathValue("id"), change.Checked)
+		writeRevision(w, revision, err)
+	}
+}
+
+func handleGetVisibleOptions(w http.ResponseWriter, r *http.Request) {
+	visible, err := pi.PI().GetVisibleOptions(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, visible)
+}
+
+func handleSetTaskOption(w http.ResponseWriter, r *http.Request) {
+	if change, ok := readChange(w, r); ok {
+		revision, err := pi.PI().SetTaskOption(change.Revision, r.PathValue("id"), r.PathValue("name"), change.Value)
+		writeRevision(w, revision, err)
+	}
+}
+
+func handleSetController(w http.ResponseWriter, r *http.Request) {
+	if change, ok := readChange(w, r); ok {
+		revision, err := pi.PI().SetController(change.Revision, change.Name)
+		writeRevision(w, revision, err)
+	}
+}
+
+func handleSetResource(w http.ResponseWriter, r *http.Request) {
+	if change, ok := readChange(w, r); ok {
+		revision, err := pi.PI().SetResource(change.Revision, change.Name)
+		writeRevision(w, revision, err)
+	}
+}
+
+func handleApplyPreset(w http.ResponseWriter, r *http.Request) {
+	if change, ok := readChange(w, r); ok {
+		revision, err := pi.PI().ApplyPreset(change.Revision, change.Name)
+		writeRevision(w, revision, err)
+	}
+}
+
+func handleGetHistory(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, history.History().GetRuns())
+}
+
+func handleGetRun(w http.ResponseWriter, r *http.Request) {
+	run, err := history.History().GetRun(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+// eventMessage is one event sent over the WebSocket
+type eventMessage struct {
+	Topic string       `json:"topic"`
+	Data  events.Event `json:"data"`
+}
+
+var upgrader = websocket.Upgrader{
+	// Clients authenticate with the token, so any origin is allowed
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// handleEvents streams every bus event to a WebSocket client
+func handleEvents(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("upgrade websocket failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// Buffer events so a slow client never blocks the publisher
+	queue := make(chan events.Event, 64)
+	unsubscribe := events.Subscribe(func(e events.Event) {
+		select {
+		case queue <- e:
+		default:
+			log.Printf("websocket client too slow, dropped event: %s", e.Topic())
+		}
+	})
+	defer unsubscribe()
+
+	// Read in the background to notice when the client goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case e := <-queue:
+			_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteJSON(eventMessage{Topic: e.Topic(), Data: e}); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("write response failed: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package remote
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"muu-alpha/backend/events"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireToken(t *testing.T) {
+	srv := httptest.NewServer(newHandler("secret"))
+	defer srv.Close()
+
+	type Case struct {
+		name     string
+		path     string
+		header   string
+		query    string
+		expected int
+	}
+
+	testCases := []Case{
+		{name: "missing token", path: "/api/config", expected: http.StatusUnauthorized},
+		{name: "wrong token", path: "/api/config", header: "Bearer nope", expected: http.StatusUnauthorized},
+		{name: "bearer token", path: "/api/config", header: "Bearer secret", expected: http.StatusOK},
+		{name: "query token outside the event stream", path: "/api/config", query: "?token=secret", expected: http.StatusUnauthorized},
+		// not a WebSocket upgrade, but past the token check
+		{name: "query token on the event stream", path: "/api/events", query: "?token=secret", expected: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+tc.path+tc.query, nil)
+			require.NoError(t, err)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, tc.expected, resp.StatusCode)
+		})
+	}
+}
+
+func TestEventsWebSocket(t *testing.T) {
+	srv := httptest.NewServer(newHandler("secret"))
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/events?token=secret"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// The subscription is registered after the upgrade, publish until the client receives one
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				events.Publish(events.EngineRunning{Running: true})
+			}
+		}
+	}()
+
+	var msg struct {
+		Topic string `json:"topic"`
+		Data  struct {
+			Running bool `json:"running"`
+		} `json:"data"`
+	}
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	require.NoError(t, conn.ReadJSON(&msg))
+
+	require.Equal(t, "engine:running", msg.Topic)
+	require.True(t, msg.Data.Running)
+}
+
+func TestEngineStartProfileTraversal(t *testing.T) {
+	srv := httptest.NewServer(newHandler("secret"))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/engine/start?profile=..%2Finterface_config", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestSaveConfigRequiresRevision(t *testing.T) {
+	srv := httptest.NewServer(newHandler("secret"))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPut, srv.URL+"/api/config", strings.NewReader(`{"task": []}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
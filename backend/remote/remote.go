@@ -0,0 +1,54 @@
+package remote
+
+import (
+	"context"
+	"log"
+	"muu-alpha/backend/appconf"
+	"muu-alpha/backend/events"
+	"sync"
+)
+
+var (
+	srvInst *service
+	srvOnce sync.Once
+)
+
+func Remote() *service {
+	srvOnce.Do(func() {
+		srvInst = &service{}
+	})
+	return srvInst
+}
+
+// Startup starts the remote API if it is enabled in the app config,
+// and restarts it whenever the remote section of the app config changes
+func Startup(ctx context.Context) {
+	s := Remote()
+	s.ctx = ctx
+
+	if err := s.apply(appconf.AppConf().GetConfig().Remote); err != nil {
+		log.Printf("start remote api failed: %v", err)
+	}
+
+	events.Subscribe(func(e events.Event) {
+		if _, ok := e.(events.AppConfigChanged); !ok {
+			return
+		}
+		// a restart waits for open requests, so it must not block the publisher.
+		// The config is read when the restart runs, so the last one applies the latest config.
+		go func() {
+			if err := s.apply(appconf.AppConf().GetConfig().Remote); err != nil {
+				log.Printf("restart remote api failed: %v", err)
+				events.Publish(events.Error("remote api: " + err.Error()))
+			}
+		}()
+	})
+}
+
+// Shutdown stops the remote API
+func Shutdown() {
+	s := Remote()
+	if err := s.apply(appconf.RemoteConfig{}); err != nil {
+		log.Printf("stop remote api failed: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"muu-alpha/backend/appconf"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type service struct {
+	ctx    context.Context
+	mu     sync.Mutex
+	conf   appconf.RemoteConfig
+	server *http.Server
+	err    error
+}
+
+// Status is the state of the remote API
+type Status struct {
+	Running bool   `json:"running"`
+	Address string `json:"address"`
+	TLS     bool   `json:"tls"`
+	Error   string `json:"error,omitempty"`
+}
+
+// GetStatus gets the state of the remote API
+func (s *service) GetStatus() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := Status{
+		Running: s.server != nil,
+		Address: s.conf.Address,
+		TLS:     s.conf.TLS(),
+	}
+	if s.err != nil {
+		status.Error = s.err.Error()
+	}
+	return status
+}
+
+// apply starts, stops or restarts the server so that it matches conf
+func (s *service) apply(conf appconf.RemoteConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.server != nil && conf == s.conf {
+		return nil
+	}
+
+	if s.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		err := s.server.Shutdown(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("shutdown remote api failed: %v", err)
+		}
+		s.server = nil
+	}
+
+	s.conf = conf
+	s.err = nil
+
+	if !conf.Enabled {
+		return nil
+	}
+	if conf.Token == "" {
+		s.err = errors.New("remote api requires a token")
+		return s.err
+	}
+
+	// Listen synchronously so bind errors are reported to the caller
+	ln, err := net.Listen("tcp", conf.Address)
+	if err != nil {
+		s.err = fmt.Errorf("listen on %s failed: %w", conf.Address, err)
+		return s.err
+	}
+
+	server := &http.Server{
+		Handler:           newHandler(conf.Token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	s.server = server
+
+	go func() {
+		var err error
+		if conf.TLS() {
+			err = server.ServeTLS(ln, conf.CertFile, conf.KeyFile)
+		} else {
+			err = server.Serve(ln)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("remote api stopped: %v", err)
+			s.mu.Lock()
+			if s.server == server {
+				s.server = nil
+				s.err = err
+			}
+			s.mu.Unlock()
+		}
+	}()
+
+	log.Printf("remote api listening on %s (tls=%v)", conf.Address, conf.TLS())
+	return nil
+}
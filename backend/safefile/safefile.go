@@ -0,0 +1,170 @@
+package safefile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxBackups is the number of backups kept per file
+const MaxBackups = 10
+
+// backupTimeFormat sorts lexically in time order
+const backupTimeFormat = "20060102-150405.000000000"
+
+// WriteFile writes data to a temp file next to path, syncs it and renames it over path,
+// so path holds either the old or the new content after a crash
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op after the rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// syncDir persists the rename, directories cannot be synced on Windows
+func syncDir(dir string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+}
+
+// BackupDir returns the directory holding the backups of path
+func BackupDir(path string) string {
+	return filepath.Join(filepath.Dir(path), "backups")
+}
+
+// backupPrefix returns the file name prefix of the backups of path
+func backupPrefix(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "."
+}
+
+// Backups returns the backups of path, newest first
+func Backups(path string) ([]string, error) {
+	entries, err := os.ReadDir(BackupDir(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	prefix := backupPrefix(path)
+	ext := filepath.Ext(path)
+	backups := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(BackupDir(path), name))
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups, nil
+}
+
+// Backup copies the current content of path into a timestamped backup and removes
+// the oldest backups beyond keep. Nothing is copied when path does not exist or
+// the newest backup has the same content.
+func Backup(path string, keep int) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	backups, err := Backups(path)
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 {
+		if newest, err := os.ReadFile(backups[0]); err == nil && bytes.Equal(newest, data) {
+			return nil
+		}
+	}
+
+	name := backupPrefix(path) + time.Now().Format(backupTimeFormat) + filepath.Ext(path)
+	backup := filepath.Join(BackupDir(path), name)
+	if err := WriteFile(backup, data, 0644); err != nil {
+		return fmt.Errorf("write backup failed: %w", err)
+	}
+
+	backups = append([]string{backup}, backups...)
+	for _, old := range backups[min(len(backups), keep):] {
+		if old != backup {
+			_ = os.Remove(old)
+		}
+	}
+	return nil
+}
+
+// Recover returns the content and path of the newest backup of path that valid accepts
+func Recover(path string, valid func(data []byte) error) ([]byte, string, error) {
+	backups, err := Backups(path)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, backup := range backups {
+		data, err := os.ReadFile(backup)
+		if err != nil {
+			continue
+		}
+		if valid(data) == nil {
+			return data, backup, nil
+		}
+	}
+	return nil, "", fmt.Errorf("no valid backup of %s", filepath.Base(path))
+}
+
+// Quarantine moves a file that cannot be read aside so it is not overwritten,
+// and returns its new path
+func Quarantine(path string) (string, error) {
+	moved := path + ".corrupt-" + time.Now().Format(backupTimeFormat)
+	if err := os.Rename(path, moved); err != nil {
+		return "", err
+	}
+	return moved, nil
+}
@@ -0,0 +1,84 @@
+package safefile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "config.json")
+
+	require.NoError(t, WriteFile(path, []byte("first"), 0644))
+	require.NoError(t, WriteFile(path, []byte("second"), 0644))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(data))
+
+	// no temp files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+}
+
+func TestBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	// nothing to back up yet
+	require.NoError(t, Backup(path, 3))
+	backups, err := Backups(path)
+	require.NoError(t, err)
+	require.Empty(t, backups)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, WriteFile(path, []byte(fmt.Sprint(i)), 0644))
+		require.NoError(t, Backup(path, 3))
+		// the same content is not backed up twice
+		require.NoError(t, Backup(path, 3))
+	}
+
+	backups, err = Backups(path)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(backups))
+
+	newest, err := os.ReadFile(backups[0])
+	require.NoError(t, err)
+	require.Equal(t, "4", string(newest))
+	oldest, err := os.ReadFile(backups[2])
+	require.NoError(t, err)
+	require.Equal(t, "2", string(oldest))
+}
+
+func TestRecover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	valid := func(data []byte) error {
+		var v map[string]interface{}
+		return json.Unmarshal(data, &v)
+	}
+
+	_, _, err := Recover(path, valid)
+	require.Error(t, err)
+
+	require.NoError(t, WriteFile(path, []byte(`{"good": true}`), 0644))
+	require.NoError(t, Backup(path, MaxBackups))
+	require.NoError(t, WriteFile(path, []byte(`{"broken"`), 0644))
+	require.NoError(t, Backup(path, MaxBackups))
+
+	data, backup, err := Recover(path, valid)
+	require.NoError(t, err)
+	require.Equal(t, `{"good": true}`, string(data))
+	require.Equal(t, BackupDir(path), filepath.Dir(backup))
+
+	moved, err := Quarantine(path)
+	require.NoError(t, err)
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(moved)
+	require.NoError(t, err)
+}
@@ -0,0 +1,172 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Draft is the JSON Schema dialect of generated schemas
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document, limited to the keywords the generator emits
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"` // false or *Schema
+	Items                *Schema            `json:"items,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Generate creates a schema for the Go type of v from its json tags.
+//
+// Fields without omitempty are required. Struct types become $defs referenced by name,
+// and unknown properties are not allowed. A `jsonschema:"enum=a|b"` tag restricts a field
+// to the listed values.
+func Generate(v interface{}, title string) *Schema {
+	g := &generator{defs: make(map[string]*Schema)}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	root := g.structSchema(t)
+	root.Schema = Draft
+	root.Title = title
+	if len(g.defs) > 0 {
+		root.Defs = g.defs
+	}
+	return root
+}
+
+// MarshalIndent encodes the schema for writing to a file
+func (s *Schema) MarshalIndent() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+type generator struct {
+	defs map[string]*Schema
+}
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	timeType       = reflect.TypeOf(time.Time{})
+)
+
+func (g *generator) schemaFor(t reflect.Type) *Schema {
+	switch t {
+	case rawMessageType:
+		return &Schema{}
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schemaFor(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+		if _, ok := g.defs[name]; !ok {
+			// reserve the name first so recursive types terminate
+			g.defs[name] = &Schema{}
+			*g.defs[name] = *g.structSchema(t)
+		}
+		return &Schema{Ref: "#/$defs/" + name}
+	default:
+		return &Schema{}
+	}
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitempty, skip := parseJSONTag(field)
+		if skip {
+			continue
+		}
+
+		prop := g.schemaFor(field.Type)
+		if enum := parseEnumTag(field); len(enum) > 0 {
+			prop.Enum = enum
+		}
+		s.Properties[name] = prop
+
+		if !omitempty {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	sort.Strings(s.Required)
+	return s
+}
+
+// parseJSONTag returns the property name of a field and whether it is optional
+func parseJSONTag(field reflect.StructField) (name string, omitempty bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
+
+// parseEnumTag returns the values of a `jsonschema:"enum=a|b"` tag
+func parseEnumTag(field reflect.StructField) []interface{} {
+	for _, part := range strings.Split(field.Tag.Get("jsonschema"), ",") {
+		if values, ok := strings.CutPrefix(part, "enum="); ok {
+			enum := []interface{}{}
+			for _, v := range strings.Split(values, "|") {
+				enum = append(enum, v)
+			}
+			return enum
+		}
+	}
+	return nil
+}
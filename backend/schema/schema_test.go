@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty" jsonschema:"enum=a|b"`
+}
+
+type testDoc struct {
+	Version int               `json:"version"`
+	Items   []testItem        `json:"items,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Enabled bool              `json:"enabled,omitempty"`
+	Skipped string            `json:"-"`
+}
+
+func TestGenerate(t *testing.T) {
+	s := Generate(testDoc{}, "Test")
+
+	require.Equal(t, Draft, s.Schema)
+	require.Equal(t, []string{"version"}, s.Required)
+	require.NotContains(t, s.Properties, "-")
+	require.Equal(t, "integer", s.Properties["version"].Type)
+	require.Equal(t, "#/$defs/testItem", s.Properties["items"].Items.Ref)
+	require.Equal(t, []interface{}{"a", "b"}, s.Defs["testItem"].Properties["kind"].Enum)
+}
+
+func TestValidate(t *testing.T) {
+	s := Generate(testDoc{}, "Test")
+
+	type Case struct {
+		Name string
+		Data string
+		Want []Error
+	}
+
+	cases := []Case{
+		{
+			Name: "valid",
+			Data: `{"version": 1, "items": [{"name": "x", "kind": "a"}], "labels": {"k": "v"}}`,
+			Want: []Error{},
+		},
+		{
+			Name: "null is accepted",
+			Data: `{"version": 1, "items": null}`,
+			Want: []Error{},
+		},
+		{
+			Name: "missing required field",
+			Data: `{"items": [{}]}`,
+			Want: []Error{
+				{Path: "version", Kind: KindRequired, Message: "missing required property"},
+				{Path: "items[0].name", Kind: KindRequired, Message: "missing required property"},
+			},
+		},
+		{
+			Name: "wrong type",
+			Data: `{"version": "1", "labels": {"k": 1}}`,
+			Want: []Error{
+				{Path: "labels.k", Kind: KindType, Message: "expected string, got number"},
+				{Path: "version", Kind: KindType, Message: "expected integer, got string"},
+			},
+		},
+		{
+			Name: "enum and unknown property",
+			Data: `{"version": 1, "items": [{"name": "x", "kind": "c", "extra": true}]}`,
+			Want: []Error{
+				{Path: "items[0].extra", Kind: KindAdditional, Message: "unknown property"},
+				{Path: "items[0].kind", Kind: KindEnum, Message: "must be one of a, b, got c"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			errs, err := s.ValidateJSON([]byte(c.Data))
+			require.NoError(t, err)
+			require.Equal(t, c.Want, errs)
+		})
+	}
+}
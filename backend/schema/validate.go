@@ -0,0 +1,188 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrorKind classifies a validation error
+type ErrorKind string
+
+const (
+	KindType       ErrorKind = "type"
+	KindRequired   ErrorKind = "required"
+	KindEnum       ErrorKind = "enum"
+	KindAdditional ErrorKind = "additional"
+)
+
+// Error is a value that does not match the schema
+type Error struct {
+	Path    string    `json:"path"` // e.g. "option.Foo.cases[2].name"
+	Kind    ErrorKind `json:"kind"`
+	Message string    `json:"message"`
+}
+
+// ValidateJSON decodes data and validates it against the schema
+func (s *Schema) ValidateJSON(data []byte) ([]Error, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return s.Validate(v), nil
+}
+
+// Validate validates a decoded JSON value against the schema
+func (s *Schema) Validate(v interface{}) []Error {
+	errs := []Error{}
+	s.validate(s, v, "", &errs)
+	return errs
+}
+
+func (s *Schema) validate(root *Schema, v interface{}, path string, errs *[]Error) {
+	if s.Ref != "" {
+		ref := root.resolve(s.Ref)
+		if ref == nil {
+			return
+		}
+		s = ref
+	}
+
+	add := func(kind ErrorKind, format string, args ...interface{}) {
+		*errs = append(*errs, Error{Path: path, Kind: kind, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// null decodes into any Go value, like encoding/json
+	if v == nil {
+		return
+	}
+
+	if s.Type != "" && !matchesType(s.Type, v) {
+		add(KindType, "expected %s, got %s", s.Type, typeOf(v))
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if e == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values := make([]string, 0, len(s.Enum))
+			for _, e := range s.Enum {
+				values = append(values, fmt.Sprint(e))
+			}
+			add(KindEnum, "must be one of %s, got %v", strings.Join(values, ", "), v)
+		}
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := value[name]; !ok {
+				*errs = append(*errs, Error{
+					Path:    join(path, name),
+					Kind:    KindRequired,
+					Message: "missing required property",
+				})
+			}
+		}
+
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if prop, ok := s.Properties[key]; ok {
+				prop.validate(root, value[key], join(path, key), errs)
+				continue
+			}
+			switch additional := s.AdditionalProperties.(type) {
+			case bool:
+				if !additional {
+					*errs = append(*errs, Error{
+						Path:    join(path, key),
+						Kind:    KindAdditional,
+						Message: "unknown property",
+					})
+				}
+			case *Schema:
+				additional.validate(root, value[key], join(path, key), errs)
+			}
+		}
+
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range value {
+				s.Items.validate(root, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	}
+}
+
+// resolve looks up a "#/$defs/<name>" reference
+func (s *Schema) resolve(ref string) *Schema {
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok {
+		return nil
+	}
+	return s.Defs[name]
+}
+
+func matchesType(t string, v interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == float64(int64(f))
+	default:
+		return true
+	}
+}
+
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// join appends a property to a path
+func join(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -2,7 +2,9 @@ package system
 
 import (
 	"context"
+	"muu-alpha/backend/events"
 	goruntime "runtime"
+	"sync"
 )
 
 var (
@@ -12,6 +14,10 @@ var (
 
 type service struct {
 	ctx context.Context
+
+	noticesMu      sync.Mutex
+	startupNotices []events.Notice
+	stopRecording  func()
 }
 
 type AppInfo struct {
@@ -31,3 +37,29 @@ func (s *service) GetAppInfo() AppInfo {
 		GoVersion: goruntime.Version(),
 	}
 }
+
+// recordNotice keeps the notices published before the frontend asked for them
+func (s *service) recordNotice(e events.Event) {
+	if notice, ok := e.(events.Notice); ok {
+		s.noticesMu.Lock()
+		s.startupNotices = append(s.startupNotices, notice)
+		s.noticesMu.Unlock()
+	}
+}
+
+// GetStartupNotices gets the notices published before the frontend was ready, e.g. a recovered config.
+// Later notices are only delivered as events.
+func (s *service) GetStartupNotices() []events.Notice {
+	if s.stopRecording != nil {
+		s.stopRecording()
+	}
+
+	s.noticesMu.Lock()
+	defer s.noticesMu.Unlock()
+	notices := s.startupNotices
+	s.startupNotices = nil
+	if notices == nil {
+		notices = []events.Notice{}
+	}
+	return notices
+}
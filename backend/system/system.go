@@ -2,6 +2,7 @@ package system
 
 import (
 	"context"
+	"muu-alpha/backend/events"
 	"sync"
 )
 
@@ -13,6 +14,8 @@ var (
 func System() *service {
 	srvOnce.Do(func() {
 		srvInst = &service{}
+		// notices published while the window loads would be missed by the frontend
+		srvInst.stopRecording = events.Subscribe(srvInst.recordNotice)
 	})
 	return srvInst
 }
@@ -20,4 +23,6 @@ func System() *service {
 func Startup(ctx context.Context) {
 	srv := System()
 	srv.ctx = ctx
+
+	events.Publish(events.AppReady{Version: AppVersion})
 }
@@ -0,0 +1,24 @@
+// schemagen writes the JSON Schemas of interface.json and interface_config.json.
+// It is run by go generate, so the schemas can be updated without building the GUI.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"muu-alpha/backend/pi"
+	"os"
+)
+
+func main() {
+	out := flag.String("out", "schema", "directory to write the schema files to")
+	flag.Parse()
+
+	paths, err := pi.WriteSchemas(*out)
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -1,6 +1,7 @@
 import { onMounted, onUnmounted } from 'vue'
 import { EventsOn } from '@wails/runtime/runtime'
 import { useToast } from 'primevue'
+import { GetStartupNotices } from '@wails/go/system/service'
 
 interface ToastConfig {
   summary: string
@@ -23,21 +24,26 @@ export function useGlobalEvents() {
   const toast = useToast()
   const cleanups: Array<() => void> = []
 
+  /** 显示通知 */
+  function show(severity: string, message: string) {
+    const config = defaultConfig[severity]
+    if (!config) return
+
+    toast.add({
+      severity,
+      summary: config.summary,
+      detail: message,
+      life: config.life,
+    })
+  }
+
   /** 注册事件监听 */
   function registerEvent(
     eventName: string,
     severity: 'error' | 'warn' | 'success' | 'info'
   ) {
-    const config = defaultConfig[severity]
-    if (!config) return
-
-    const cleanup = EventsOn(eventName, (message: string) => {
-      toast.add({
-        severity,
-        summary: config.summary,
-        detail: message,
-        life: config.life,
-      })
+    const cleanup = EventsOn(eventName, (notice: { message: string }) => {
+      show(severity, notice.message)
     })
     cleanups.push(cleanup)
   }
@@ -48,6 +54,13 @@ export function useGlobalEvents() {
     registerEvent('app:warn', 'warn')
     registerEvent('app:success', 'success')
     registerEvent('app:info', 'info')
+
+    // 启动期间（窗口加载前）发出的通知
+    GetStartupNotices()
+      .then((notices) => {
+        notices.forEach((notice) => show(notice.level, notice.message))
+      })
+      .catch((e) => console.error('Failed to get startup notices:', e))
   }
 
   /** 清理所有事件监听 */
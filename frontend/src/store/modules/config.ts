@@ -1,6 +1,15 @@
 import { defineStore } from 'pinia'
 import { ref, computed, watch } from 'vue'
-import { GetConfig, SaveConfig } from '@wails/go/pi/service'
+import {
+  GetConfig,
+  GetRevision,
+  SaveConfig,
+  Undo,
+  Redo,
+  ApplyPreset,
+  SetController,
+  SetResource,
+} from '@wails/go/pi/service'
 import {
   GetConfig as GetAppConfig,
   SaveConfig as SaveAppConfig,
@@ -16,6 +25,8 @@ export const useConfigStore = defineStore('config', () => {
 
 
   const piConfig = ref<pi.InterfaceConfig | null>(null)
+  /** Revision of piConfig, changes with an older revision are already loaded */
+  const revision = ref(0)
   const appConfig = ref<appconf.AppConfig | null>(null)
   const appSupported = ref<appconf.Supported | null>(null)
 
@@ -110,12 +121,16 @@ export const useConfigStore = defineStore('config', () => {
       // Initialize system preference detection
       initSystemPreference()
 
+      // Read the revision first, a change in between is loaded again on its event
+      const configRevision = await GetRevision()
+
       // Load all configs in parallel
       const [interfaceConfig, appConfigData, supportedData] = await Promise.all(
         [GetConfig(), GetAppConfig(), GetSupported()]
       )
 
       piConfig.value = interfaceConfig
+      revision.value = configRevision
       appConfig.value = appConfigData
       appSupported.value = supportedData
 
@@ -132,7 +147,50 @@ export const useConfigStore = defineStore('config', () => {
     }
   }
 
-  /** Save all configs to backend */
+  /** Load the task configuration again, after it was changed by the backend */
+  async function reloadConfig() {
+    try {
+      // Read the revision first, a change in between is loaded again on its event
+      const configRevision = await GetRevision()
+      piConfig.value = await GetConfig()
+      revision.value = configRevision
+    } catch (e) {
+      error.value = e instanceof Error ? e.message : String(e)
+      console.error('Failed to reload config:', e)
+    }
+  }
+
+  /**
+   * Apply a change of the task configuration through the granular bindings, which check the values.
+   * change gets the current revision and returns the new one, the config is reloaded either way.
+   */
+  async function mutate(change: (revision: number) => Promise<number>) {
+    error.value = null
+    try {
+      await change(revision.value)
+    } catch (e) {
+      error.value = e instanceof Error ? e.message : String(e)
+      console.error('Failed to change config:', e)
+    }
+    await reloadConfig()
+  }
+
+  /** Save the app config to backend */
+  async function saveAppConfig() {
+    if (!appConfig.value) return
+
+    try {
+      await SaveAppConfig(appConfig.value)
+    } catch (e) {
+      error.value = e instanceof Error ? e.message : String(e)
+      console.error('Failed to save app config:', e)
+    }
+  }
+
+  /**
+   * Save all configs to backend. The task configuration is only replaced when nobody changed it
+   * since it was loaded, otherwise it is loaded again.
+   */
   async function save() {
     if (saving.value) return
 
@@ -143,7 +201,11 @@ export const useConfigStore = defineStore('config', () => {
       const savePromises: Promise<void>[] = []
 
       if (piConfig.value) {
-        savePromises.push(SaveConfig(piConfig.value))
+        savePromises.push(
+          SaveConfig(revision.value, piConfig.value).then((rev) => {
+            revision.value = rev
+          })
+        )
       }
 
       if (appConfig.value) {
@@ -154,18 +216,45 @@ export const useConfigStore = defineStore('config', () => {
     } catch (e) {
       error.value = e instanceof Error ? e.message : String(e)
       console.error('Failed to save config:', e)
+      await reloadConfig()
     } finally {
       saving.value = false
     }
   }
 
-  /** Set controller configuration */
-  function setController(ctrl: pi.ConfigController) {
-    if (piConfig.value) {
-      piConfig.value.controller = ctrl
+  /** Undo the last change of the task configuration, the change event reloads it */
+  async function undo() {
+    try {
+      await Undo()
+    } catch (e) {
+      console.warn('Nothing to undo:', e)
     }
   }
 
+  /** Redo the last undone change of the task configuration */
+  async function redo() {
+    try {
+      await Redo()
+    } catch (e) {
+      console.warn('Nothing to redo:', e)
+    }
+  }
+
+  /** Replace the tasks with a preset of the interface */
+  async function applyPreset(name: string) {
+    await mutate((rev) => ApplyPreset(rev, name))
+  }
+
+  /** Select a controller of the interface, options depending on it are synced by the backend */
+  async function selectController(name: string) {
+    await mutate((rev) => SetController(rev, name))
+  }
+
+  /** Select a resource of the interface, options depending on it are synced by the backend */
+  async function selectResource(name: string) {
+    await mutate((rev) => SetResource(rev, name))
+  }
+
   /** Set ADB configuration */
   function setAdb(adbConfig: pi.ConfigAdb | undefined) {
     if (piConfig.value) {
@@ -180,19 +269,7 @@ export const useConfigStore = defineStore('config', () => {
     }
   }
 
-  /** Set resource */
-  function setResource(res: string) {
-    if (piConfig.value) {
-      piConfig.value.resource = res
-    }
-  }
 
-  /** Set task list */
-  function setTasks(taskList: pi.ConfigTask[]) {
-    if (piConfig.value) {
-      piConfig.value.task = taskList
-    }
-  }
 
   /** Ensure config is initialized */
   function ensureConfig() {
@@ -210,7 +287,7 @@ export const useConfigStore = defineStore('config', () => {
     if (!appConfig.value) return
 
     appConfig.value.theme = newTheme
-    await save()
+    await saveAppConfig()
   }
 
   /** Set language */
@@ -219,7 +296,7 @@ export const useConfigStore = defineStore('config', () => {
 
     appConfig.value.language = newLanguage
     ;(i18n.global.locale as any).value = newLanguage
-    await save()
+    await saveAppConfig()
   }
 
   // Watch for theme changes and apply
@@ -230,6 +307,7 @@ export const useConfigStore = defineStore('config', () => {
   return {
     // State
     piConfig,
+    revision,
     appConfig,
     appSupported,
     loading,
@@ -253,12 +331,17 @@ export const useConfigStore = defineStore('config', () => {
 
     // Actions
     load,
+    reloadConfig,
+    mutate,
+    saveAppConfig,
     save,
-    setController,
+    undo,
+    redo,
+    applyPreset,
+    selectController,
+    selectResource,
     setAdb,
     setWin32,
-    setResource,
-    setTasks,
     ensureConfig,
     setTheme,
     setLanguage,
@@ -1,6 +1,10 @@
 import { defineStore } from 'pinia'
 import { ref, computed } from 'vue'
-import { V2Loaded as LoadV2, GetVersion } from '@wails/go/pi/service'
+import {
+  V2Loaded as LoadV2,
+  GetVersion,
+  GetLocalizedInterface,
+} from '@wails/go/pi/service'
 import { pi } from '@wails/go/models'
 
 export const PI_VERSION = {
@@ -16,21 +20,21 @@ export const usePiStore = defineStore('pi', () => {
   const loading = ref(false)
   const error = ref<string | null>(null)
   const interfaceVersion = ref<number>(PI_VERSION.UNKNOWN)
+  const localized = ref<pi.LocalizedInterface | null>(null)
 
   // ============ Getters ============
 
   /** Whether the PI is loaded */
   const isLoaded = computed(() => loaded.value !== null)
 
-  /** Project interface information */
-  const piInterface = computed(() => loaded.value?.Interface ?? null)
+  /** Project interface information, with its strings resolved once the localized copy is loaded */
+  const piInterface = computed(
+    () => localized.value?.Interface ?? loaded.value?.Interface ?? null
+  )
 
   /** Base path */
   const basePath = computed(() => loaded.value?.BasePath ?? '')
 
-  /** i18n resolver */
-  const resolvers = computed(() => loaded.value?.Resolvers ?? {})
-
   /** Task list */
   const tasks = computed(() => piInterface.value?.task ?? [])
 
@@ -43,6 +47,9 @@ export const usePiStore = defineStore('pi', () => {
   /** Resource list */
   const resources = computed(() => piInterface.value?.resource ?? [])
 
+  /** Preset list */
+  const presets = computed(() => piInterface.value?.preset ?? [])
+
   /** Agent configuration */
   const agent = computed(() => piInterface.value?.agent ?? null)
 
@@ -100,12 +107,9 @@ export const usePiStore = defineStore('pi', () => {
       const ver = await GetVersion()
       interfaceVersion.value = ver
 
-      // only V2 version can get V2Loaded
-      if (ver === PI_VERSION.V2) {
+      // v1 interfaces are converted to v2 by the backend
+      if (ver === PI_VERSION.V2 || ver === PI_VERSION.V1) {
         loaded.value = await LoadV2()
-      } else if (ver === PI_VERSION.V1) {
-        error.value = 'v1 project interface is not supported'
-        console.warn('v1 project interface is not supported')
       } else {
         error.value = 'unknown project interface version'
         console.error('Unknown PI version:', ver)
@@ -118,6 +122,16 @@ export const usePiStore = defineStore('pi', () => {
     }
   }
 
+  /** Load the interface with every string resolved by the backend for locale */
+  async function loadLocalized(locale: string) {
+    try {
+      localized.value = await GetLocalizedInterface(locale)
+    } catch (e) {
+      localized.value = null
+      console.error('Failed to load localized PI:', e)
+    }
+  }
+
   /** Get task by name */
   function getTaskByName(name: string): pi.V2Task | null {
     return tasks.value.find((t: pi.V2Task) => t.name === name) ?? null
@@ -153,24 +167,13 @@ export const usePiStore = defineStore('pi', () => {
     return tasks.value.filter((t: pi.V2Task) => t.default_check)
   }
 
-  /** Resolve i18n string */
-  function resolveI18n(str: string | undefined, locale = 'zh-CN'): string {
+  /**
+   * Display text of an interface string. Strings are translated by the backend,
+   * a key that is not resolved yet is shown without its "$".
+   */
+  function resolveI18n(str: string | undefined): string {
     if (!str) return ''
-    // if not i18n string (not start with $), return directly
-    if (!str.startsWith('$') || str.length === 1) return str
-
-    const key = str.slice(1)
-    const resolver = resolvers.value[locale]
-    if (resolver && typeof resolver === 'object' && key in resolver) {
-      return (resolver as Record<string, string>)[key] ?? str
-    }
-
-    // try to use languages in interface
-    const languages = piInterface.value?.languages
-    if (languages && key in languages) {
-      return languages[key] ?? str
-    }
-
+    if (str.startsWith('$') && str.length > 1) return str.slice(1)
     return str
   }
 
@@ -180,6 +183,7 @@ export const usePiStore = defineStore('pi', () => {
     loading.value = false
     error.value = null
     interfaceVersion.value = PI_VERSION.UNKNOWN
+    localized.value = null
   }
 
   return {
@@ -188,17 +192,18 @@ export const usePiStore = defineStore('pi', () => {
     loading,
     error,
     interfaceVersion,
+    localized,
 
     // Getters
     isLoaded,
     isV2,
     piInterface,
     basePath,
-    resolvers,
     tasks,
     options,
     controllers,
     resources,
+    presets,
     agent,
     name,
     label,
@@ -214,6 +219,7 @@ export const usePiStore = defineStore('pi', () => {
 
     // Actions
     load,
+    loadLocalized,
     getTaskByName,
     getOptionByName,
     getControllerByName,
@@ -3,6 +3,13 @@ import { ref, computed } from 'vue'
 import { usePiStore } from './pi'
 import { useConfigStore } from './config'
 import { pi } from '@wails/go/models'
+import {
+  AddTask,
+  RemoveTask,
+  MoveTask,
+  SetTaskChecked,
+  SetTaskOption,
+} from '@wails/go/pi/service'
 import { Start, Stop, GetIsRunning } from '@wails/go/engine/service'
 import { EventsOn } from '@wails/runtime/runtime'
 
@@ -13,12 +20,46 @@ export interface TaskListItem {
   checked: boolean
 }
 
-/** Option value for a task (option name -> selected value) */
-export type TaskOptionValues = Record<string, string>
+/** Value of an input of an input option, typed by its kind */
+export type InputValue = string | number | boolean | string[]
 
-/** Generate UUID */
-function generateUUID(): string {
-  return crypto.randomUUID()
+/**
+ * Value of an option: the case name of a select or switch, the case names of a checkbox,
+ * or the values of an input option by input name
+ */
+export type TaskOptionValue = string | string[] | Record<string, InputValue>
+
+/** Option value for a task (option name -> value) */
+export type TaskOptionValues = Record<string, TaskOptionValue>
+
+/** Case names selected by a checkbox option */
+export function caseNames(value: TaskOptionValue | undefined): string[] {
+  return Array.isArray(value) ? value : []
+}
+
+/** Kind of an input, inputs without one follow their pipeline_type */
+export function inputKind(input: pi.V2OptionInput): string {
+  if (input.kind) return input.kind
+  return input.pipeline_type === 'int' || input.pipeline_type === 'bool' ? input.pipeline_type : 'text'
+}
+
+/** Convert the text of an input to its kind, text that is not a number is kept for validation */
+export function convertInputValue(input: pi.V2OptionInput, value: string): InputValue {
+  switch (inputKind(input)) {
+    case 'int':
+      return /^\s*-?\d+\s*$/.test(value) ? parseInt(value, 10) : value
+    case 'float':
+      return value.trim() !== '' && Number.isFinite(Number(value)) ? Number(value) : value
+    case 'bool':
+      return value === 'true' || value === 'True' || value === '1'
+    case 'list':
+      return value
+        .split('\n')
+        .map((line) => line.trim())
+        .filter(Boolean)
+    default:
+      return value
+  }
 }
 
 export const useTaskListStore = defineStore('taskList', () => {
@@ -36,6 +77,9 @@ export const useTaskListStore = defineStore('taskList', () => {
   /** Option values for each task (task id -> option values) */
   const taskOptionValues = ref<Record<string, TaskOptionValues>>({})
 
+  /** Config tasks the interface no longer has, kept until the user removes them */
+  const orphanedTasks = ref<pi.ConfigTask[]>([])
+
   /** Engine running state */
   const isRunning = ref(false)
 
@@ -96,6 +140,7 @@ export const useTaskListStore = defineStore('taskList', () => {
     // clear existing task list and option values
     taskList.value = []
     taskOptionValues.value = {}
+    orphanedTasks.value = []
 
     // restore task list from config
     for (const configTask of configTasks) {
@@ -115,161 +160,113 @@ export const useTaskListStore = defineStore('taskList', () => {
           }
           taskOptionValues.value[configTask.id] = savedOptions
         }
+      } else {
+        orphanedTasks.value.push(configTask)
       }
     }
   }
 
-  /** Sync task list to config and save */
-  async function syncToConfig() {
-    // update task list in config
-    configStore.setTasks(
-      taskList.value.map((item) => {
-        // Convert option values to ConfigTaskOption array
-        const optionValues = taskOptionValues.value[item.id] ?? {}
-        const options: pi.ConfigTaskOption[] = Object.entries(optionValues).map(
-          ([name, value]) =>
-            pi.ConfigTaskOption.createFrom({ name, value })
-        )
-
-        return pi.ConfigTask.createFrom({
-          id: item.id,
-          name: item.task.name,
-          checked: item.checked,
-          option: options,
-        })
-      })
-    )
-
-    // save to backend
-    await configStore.save()
+  /**
+   * Apply a change through the granular bindings, which check the values and sync nested options.
+   * The task list is reloaded from the config the backend kept, also when the change failed.
+   */
+  async function mutate(change: (revision: number) => Promise<number>) {
+    await configStore.mutate(change)
+    loadFromConfig()
   }
 
-  // ============ Actions ============
-
-  /** Initialize task list (add default tasks according to default_check) */
-  function initFromPi() {
-    const defaultTasks = piStore.getDefaultCheckedTasks()
-    taskList.value = defaultTasks.map((task) => {
-      const id = generateUUID()
-      return {
-        id,
-        task,
-        checked: true,
+  /** Drop the tasks the interface no longer has */
+  async function removeOrphanedTasks() {
+    const ids = orphanedTasks.value.map((task) => task.id)
+    await mutate(async (revision) => {
+      for (const id of ids) {
+        revision = await RemoveTask(revision, id)
       }
+      return revision
     })
   }
 
-  /** Add task to list (same task can be added repeatedly) */
-  function addTask(task: pi.V2Task, checked = false): string {
-    const id = generateUUID()
-    taskList.value.push({ id, task, checked })
-
-    // Initialize default option values for the new task
-    if (task.option && task.option.length > 0) {
-      const defaults: TaskOptionValues = {}
-      initOptionDefaults(defaults, task.option)
-      taskOptionValues.value[id] = defaults
-    }
+  // ============ Actions ============
 
-    syncToConfig()
-    return id
+  /** Add a task to the end of the list with its default options (same task can be added repeatedly) */
+  async function addTask(task: pi.V2Task) {
+    await mutate((revision) => AddTask(revision, task.name, -1))
   }
 
   /** Add tasks in batch */
-  function addTasks(tasks: pi.V2Task[], checked = false): string[] {
-    const ids = tasks.map((task) => {
-      const id = generateUUID()
-      taskList.value.push({ id, task, checked })
-
-      // Initialize default option values for each new task
-      if (task.option && task.option.length > 0) {
-        const defaults: TaskOptionValues = {}
-        initOptionDefaults(defaults, task.option)
-        taskOptionValues.value[id] = defaults
+  async function addTasks(tasks: pi.V2Task[]) {
+    await mutate(async (revision) => {
+      for (const task of tasks) {
+        revision = await AddTask(revision, task.name, -1)
       }
-
-      return id
+      return revision
     })
-    syncToConfig()
-    return ids
   }
 
   /** Remove task from list by id */
-  function removeTask(id: string) {
-    const index = taskList.value.findIndex((item) => item.id === id)
-    if (index !== -1) {
-      taskList.value.splice(index, 1)
-      // Clear option values for removed task
-      delete taskOptionValues.value[id]
-      // Clear selection if removed task was selected
-      if (selectedTaskId.value === id) {
-        selectedTaskId.value = null
-      }
-      syncToConfig()
-      return true
+  async function removeTask(id: string) {
+    // Clear selection if removed task was selected
+    if (selectedTaskId.value === id) {
+      selectedTaskId.value = null
     }
-    return false
+    await mutate((revision) => RemoveTask(revision, id))
+  }
+
+  /** Set the checked state of tasks by id */
+  async function setChecked(ids: string[], checked: boolean) {
+    await mutate(async (revision) => {
+      for (const id of ids) {
+        revision = await SetTaskChecked(revision, id, checked)
+      }
+      return revision
+    })
   }
 
   /** Set task checked state by id */
-  function setTaskChecked(id: string, checked: boolean) {
-    const item = taskList.value.find((item) => item.id === id)
-    if (item) {
-      item.checked = checked
-      syncToConfig()
-    }
+  async function setTaskChecked(id: string, checked: boolean) {
+    await setChecked([id], checked)
   }
 
   /** Toggle task checked state by id */
-  function toggleTaskChecked(id: string) {
+  async function toggleTaskChecked(id: string) {
     const item = taskList.value.find((item) => item.id === id)
     if (item) {
-      item.checked = !item.checked
-      syncToConfig()
+      await setChecked([id], !item.checked)
     }
   }
 
   /** Check all */
-  function checkAll() {
-    taskList.value.forEach((item) => {
-      item.checked = true
-    })
-    syncToConfig()
+  async function checkAll() {
+    await setChecked(
+      taskList.value.filter((item) => !item.checked).map((item) => item.id),
+      true
+    )
   }
 
   /** Uncheck all */
-  function uncheckAll() {
-    taskList.value.forEach((item) => {
-      item.checked = false
-    })
-    syncToConfig()
-  }
-
-  /** Clear task list */
-  function clear() {
-    taskList.value = []
-    selectedTaskId.value = null
-    taskOptionValues.value = {}
-  }
-
-  /** Reset (clear and re-initialize from PI) */
-  function reset() {
-    clear()
-    initFromPi()
-    syncToConfig()
+  async function uncheckAll() {
+    await setChecked(
+      taskList.value.filter((item) => item.checked).map((item) => item.id),
+      false
+    )
   }
 
   /** Move task position (drag and sort) */
-  function moveTask(fromIndex: number, toIndex: number) {
+  async function moveTask(fromIndex: number, toIndex: number) {
     if (fromIndex === toIndex) return
     if (fromIndex < 0 || fromIndex >= taskList.value.length) return
     if (toIndex < 0 || toIndex >= taskList.value.length) return
 
-    const item = taskList.value.splice(fromIndex, 1)[0]
-    if (item) {
-      taskList.value.splice(toIndex, 0, item)
-      syncToConfig()
-    }
+    // indexes of the backend count the orphaned tasks too
+    const item = taskList.value[fromIndex]
+    const target = taskList.value[toIndex]
+    if (!item || !target) return
+    const index = configStore.tasks.findIndex((task) => task.id === target.id)
+
+    // move it right away, so the list does not jump back while dragging
+    taskList.value.splice(fromIndex, 1)
+    taskList.value.splice(toIndex, 0, item)
+    await mutate((revision) => MoveTask(revision, item.id, index))
   }
 
   /** Get task index by id */
@@ -282,137 +279,40 @@ export const useTaskListStore = defineStore('taskList', () => {
     selectedTaskId.value = id
   }
 
-  /** Set option value for a task */
-  function setOptionValue(taskId: string, optionName: string, value: string, inputName?: string) {
-    if (!taskOptionValues.value[taskId]) {
-      taskOptionValues.value[taskId] = {}
-    }
-    const taskOpts = taskOptionValues.value[taskId]
-    if (!taskOpts) return
-
-    const key = inputName ? `${optionName}.${inputName}` : optionName
-    const oldValue = taskOpts[key]
-    taskOpts[key] = value
-
-    // If this is a select/switch option, sync nested options for real-time UI update
-    if (!inputName && oldValue !== value) {
-      const option = piStore.getOptionByName(optionName)
-      if (option && (option.type === 'select' || option.type === 'switch' || !option.type)) {
-        // Remove old nested options
-        const oldCase = option.cases?.find((c) => c.name === oldValue)
-        if (oldCase?.option) {
-          removeNestedOptions(taskOpts, oldCase.option)
-        }
-
-        // Add new nested options with defaults
-        const newCase = option.cases?.find((c) => c.name === value)
-        if (newCase?.option) {
-          const defaults: TaskOptionValues = {}
-          initOptionDefaults(defaults, newCase.option)
-          for (const [k, v] of Object.entries(defaults)) {
-            if (!(k in taskOpts)) {
-              taskOpts[k] = v
-            }
-          }
-        }
-      }
-    }
-
-    syncToConfig()
-  }
-
-  /** Remove nested options recursively (for real-time UI update when switching options) */
-  function removeNestedOptions(taskOpts: TaskOptionValues, optionNames: string[]) {
-    for (const optionName of optionNames) {
-      const option = piStore.getOptionByName(optionName)
-      if (!option) continue
-
-      if (option.type === 'input') {
-        // Remove all input fields
-        if (option.inputs) {
-          for (const input of option.inputs) {
-            delete taskOpts[`${optionName}.${input.name}`]
-          }
-        }
-      } else {
-        // Remove the option itself
-        const currentValue = taskOpts[optionName]
-        delete taskOpts[optionName]
-
-        // Remove nested options of the currently selected case
-        const currentCase = option.cases?.find((c) => c.name === currentValue)
-        if (currentCase?.option) {
-          removeNestedOptions(taskOpts, currentCase.option)
-        }
-      }
-    }
-  }
-
-  /** Initialize option defaults (for real-time UI update when switching options) */
-  function initOptionDefaults(values: TaskOptionValues, optionNames: string[]) {
-    for (const optionName of optionNames) {
-      const option = piStore.getOptionByName(optionName)
-      if (!option) continue
-
-      if (option.type === 'select' || !option.type) {
-        let selectedCase: pi.V2OptionCase | undefined
-        if (option.default_case) {
-          values[optionName] = option.default_case
-          selectedCase = option.cases?.find((c) => c.name === option.default_case)
-        } else if (option.cases && option.cases.length > 0) {
-          const firstCase = option.cases[0]
-          if (firstCase) {
-            values[optionName] = firstCase.name
-            selectedCase = firstCase
-          }
-        } else {
-          values[optionName] = ''
-        }
-
-        if (selectedCase?.option && selectedCase.option.length > 0) {
-          initOptionDefaults(values, selectedCase.option)
-        }
-      } else if (option.type === 'switch') {
-        const cases = option.cases ?? []
-        const noCase = cases.find(
-          (c) => !['Yes', 'yes', 'Y', 'y'].includes(c.name)
-        )
-        values[optionName] = noCase?.name ?? 'No'
-
-        if (noCase?.option && noCase.option.length > 0) {
-          initOptionDefaults(values, noCase.option)
-        }
-      } else if (option.type === 'input') {
-        if (option.inputs) {
-          for (const input of option.inputs) {
-            let value = input.default ?? ''
-            if (!value) {
-              switch (input.pipeline_type) {
-                case 'bool':
-                  value = 'false'
-                  break
-                case 'int':
-                  value = '0'
-                  break
-                default:
-                  value = ''
-              }
-            }
-            values[`${optionName}.${input.name}`] = value
-          }
-        }
+  /**
+   * Set option value for a task, with inputName the text of one input of an input option.
+   * Nested options of the previous and the new case are synced by the backend.
+   */
+  async function setOptionValue(
+    taskId: string,
+    optionName: string,
+    value: TaskOptionValue,
+    inputName?: string
+  ) {
+    if (inputName) {
+      const input = piStore
+        .getOptionByName(optionName)
+        ?.inputs?.find((i) => i.name === inputName)
+      // the values of the other inputs are kept by the backend
+      value = {
+        [inputName]: input ? convertInputValue(input, String(value)) : String(value),
       }
     }
+    await mutate((revision) => SetTaskOption(revision, taskId, optionName, value))
   }
 
   /** Set multiple option values for a task */
-  function setOptionValues(taskId: string, values: TaskOptionValues) {
-    taskOptionValues.value[taskId] = { ...taskOptionValues.value[taskId], ...values }
-    syncToConfig()
+  async function setOptionValues(taskId: string, values: TaskOptionValues) {
+    await mutate(async (revision) => {
+      for (const [name, value] of Object.entries(values)) {
+        revision = await SetTaskOption(revision, taskId, name, value)
+      }
+      return revision
+    })
   }
 
   /** Get option value for a task */
-  function getOptionValue(taskId: string, optionName: string): string | undefined {
+  function getOptionValue(taskId: string, optionName: string): TaskOptionValue | undefined {
     return taskOptionValues.value[taskId]?.[optionName]
   }
 
@@ -427,8 +327,8 @@ export const useTaskListStore = defineStore('taskList', () => {
     isRunning.value = await GetIsRunning()
 
     // Subscribe to running state changes
-    unsubscribeRunning = EventsOn('engine:running', (running: boolean) => {
-      isRunning.value = running
+    unsubscribeRunning = EventsOn('engine:running', (e: { running: boolean }) => {
+      isRunning.value = e.running
     })
   }
 
@@ -464,6 +364,7 @@ export const useTaskListStore = defineStore('taskList', () => {
     taskList,
     selectedTaskId,
     taskOptionValues,
+    orphanedTasks,
     isRunning,
 
     // Getters
@@ -479,10 +380,9 @@ export const useTaskListStore = defineStore('taskList', () => {
 
     // Config Sync
     loadFromConfig,
-    syncToConfig,
+    removeOrphanedTasks,
 
     // Actions
-    initFromPi,
     addTask,
     addTasks,
     removeTask,
@@ -490,8 +390,6 @@ export const useTaskListStore = defineStore('taskList', () => {
     toggleTaskChecked,
     checkAll,
     uncheckAll,
-    clear,
-    reset,
     moveTask,
     getTaskIndex,
     selectTask,
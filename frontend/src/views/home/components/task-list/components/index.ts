@@ -1,6 +1,8 @@
 export { default as TaskHeader } from './task-header.vue'
 export { default as TaskPicker } from './task-picker.vue'
+export { default as PresetPicker } from './preset-picker.vue'
 export { default as LoadingState } from './loading-state.vue'
 export { default as ErrorState } from './error-state.vue'
 export { default as EmptyState } from './empty-state.vue'
 export { default as TaskItem } from './task-item.vue'
+export { default as OrphanedTasks } from './orphaned-tasks.vue'
@@ -3,10 +3,16 @@ package main
 import (
 	"context"
 	"embed"
+	"flag"
+	"log"
 	"muu-alpha/backend/appconf"
+	"muu-alpha/backend/cli"
 	"muu-alpha/backend/engine"
+	"muu-alpha/backend/events"
 	"muu-alpha/backend/fileloader"
+	"muu-alpha/backend/history"
 	"muu-alpha/backend/pi"
+	"muu-alpha/backend/remote"
 	"muu-alpha/backend/system"
 	"net/http"
 	"os"
@@ -17,13 +23,28 @@ import (
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
 )
 
+//go:generate go run ./cmd/schemagen -out schema
+
 //go:embed all:frontend/dist
 var assets embed.FS
 
 func main() {
+	if cli.IsCommand(os.Args[1:]) {
+		os.Exit(cli.Main(os.Args[1:]))
+	}
+
+	guiFlags := flag.NewFlagSet("muu-alpha", flag.ContinueOnError)
+	project := guiFlags.String("project", "", "path to the interface.json to open")
+	if err := guiFlags.Parse(os.Args[1:]); err != nil {
+		log.Printf("parse flags failed: %v", err)
+	}
+	pi.SetStartupProject(*project)
+
 	piSrv := pi.PI()
 	appConfSrv := appconf.AppConf()
 	engSrv := engine.Engine()
+	histSrv := history.History()
+	remoteSrv := remote.Remote()
 	sysSrv := system.System()
 
 	exePath, err := os.Executable()
@@ -36,8 +57,13 @@ func main() {
 	assetsDir := filepath.Join(exeDir, "static")
 	assetsLoader := fileloader.New(assetsDir)
 	mux.Handle("/static/", http.StripPrefix("/static/", assetsLoader))
-	resDir := filepath.Join(exeDir, "resource")
-	resLoader := fileloader.New(resDir)
+	// project resources follow the active project
+	resLoader := fileloader.NewFunc(func() string {
+		if v2Loaded := piSrv.V2Loaded(); v2Loaded != nil {
+			return filepath.Join(v2Loaded.BasePath, "resource")
+		}
+		return filepath.Join(exeDir, "resource")
+	})
 	mux.Handle("/resource/", http.StripPrefix("/resource/", resLoader))
 
 	err = wails.Run(&options.App{
@@ -51,15 +77,23 @@ func main() {
 		Frameless:        true,
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup: func(ctx context.Context) {
+			events.ForwardToWails(ctx)
 			pi.Startup(ctx)
 			appconf.Startup(ctx)
 			engine.Startup(ctx)
+			history.Startup(ctx)
+			remote.Startup(ctx)
 			system.Startup(ctx)
 		},
+		OnShutdown: func(ctx context.Context) {
+			remote.Shutdown()
+		},
 		Bind: []interface{}{
 			piSrv,
 			appConfSrv,
 			engSrv,
+			histSrv,
+			remoteSrv,
 			sysSrv,
 		},
 	})
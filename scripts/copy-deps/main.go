@@ -18,6 +18,8 @@ type DepConfig struct {
 	DstDir string // Destination directory (relative to output base directory)
 	// Blacklist is an optional per-dependency map of OS -> filename patterns to skip.
 	Blacklist map[string][]string
+	// Always copies files even if they exist, for files that change with the source
+	Always bool
 }
 
 // Dependencies to copy. Add new entries here to copy additional dependencies.
@@ -50,6 +52,12 @@ var deps = []DepConfig{
 		SrcDir: "deps/MaaFramework/share/MaaAgentBinary",
 		DstDir: "share/MaaAgentBinary",
 	},
+	{
+		Name:   "Schema",
+		SrcDir: "schema",
+		DstDir: "schema",
+		Always: true,
+	},
 	// Add more dependencies here, e.g.:
 	// {
 	// 	Name:   "AnotherDep",
@@ -202,7 +210,7 @@ func copyDir(src, dst, relBase string, dep DepConfig, stats *copyStats) {
 			copyDir(srcPath, dstPath, relPath, dep, stats)
 		} else {
 			// Skip if file exists and not force mode
-			if !*forceFlag {
+			if !*forceFlag && !dep.Always {
 				if _, err := os.Stat(dstPath); err == nil {
 					fmt.Printf("Skipped: %s (exists)\n", entry.Name())
 					stats.skipped++